@@ -0,0 +1,150 @@
+package utr
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	listenFDNamesEnv    = "LISTEN_FDNAMES"
+	listenFDsEnv        = "LISTEN_FDS"
+	listenFDsStart      = 3
+	listenFDNamesSep    = ":"
+	listenPIDEnv        = "LISTEN_PID"
+	unknownListenFDName = "unknown"
+)
+
+// Listener passed by the systemd socket activation.
+type ActivatedListener struct {
+	Listener net.Listener
+	Name     string
+}
+
+// Returns listeners passed by the systemd socket activation in the order of file
+// descriptors.
+//
+// Names of listeners are taken from the LISTEN_FDNAMES environment variable, if it is
+// not set, then listeners are named as "unknown".
+//
+// If the process was not started by the systemd socket activation, then nil is
+// returned.
+//
+// Environment variables of the systemd socket activation are unset so that they are
+// not inherited by child processes, so listeners can be obtained only once.
+func ActivationListeners() ([]ActivatedListener, error) {
+	defer unsetActivationEnv()
+
+	fds, names, err := parseActivationEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	listeners := make([]ActivatedListener, 0, fds)
+
+	for id := range fds {
+		fd := listenFDsStart + id
+
+		closeOnExec(fd)
+
+		listener, err := fileListener(fd, names[id])
+		if err != nil {
+			closeActivatedListeners(listeners)
+			return nil, err
+		}
+
+		activated := ActivatedListener{
+			Listener: listener,
+			Name:     names[id],
+		}
+
+		listeners = append(listeners, activated)
+	}
+
+	if len(listeners) == 0 {
+		return nil, nil
+	}
+
+	return listeners, nil
+}
+
+func parseActivationEnv() (int, []string, error) {
+	pidEnv := os.Getenv(listenPIDEnv)
+	if pidEnv == "" {
+		return 0, nil, nil
+	}
+
+	pid, err := strconv.Atoi(pidEnv)
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %s: %w", ErrActivationInvalid, listenPIDEnv, err)
+	}
+
+	if pid != os.Getpid() {
+		return 0, nil, nil
+	}
+
+	fds, err := strconv.Atoi(os.Getenv(listenFDsEnv))
+	if err != nil {
+		return 0, nil, fmt.Errorf("%w: %s: %w", ErrActivationInvalid, listenFDsEnv, err)
+	}
+
+	if fds < 0 {
+		return 0, nil, fmt.Errorf("%w: %s: negative", ErrActivationInvalid, listenFDsEnv)
+	}
+
+	namesEnv, exists := os.LookupEnv(listenFDNamesEnv)
+	if !exists {
+		names := make([]string, fds)
+
+		for id := range names {
+			names[id] = unknownListenFDName
+		}
+
+		return fds, names, nil
+	}
+
+	names := strings.Split(namesEnv, listenFDNamesSep)
+
+	if len(names) != fds {
+		return 0, nil, fmt.Errorf(
+			"%w: %s: quantity of names is not equal to %s",
+			ErrActivationInvalid,
+			listenFDNamesEnv,
+			listenFDsEnv,
+		)
+	}
+
+	return fds, names, nil
+}
+
+func unsetActivationEnv() {
+	_ = os.Unsetenv(listenPIDEnv)
+	_ = os.Unsetenv(listenFDsEnv)
+	_ = os.Unsetenv(listenFDNamesEnv)
+}
+
+func fileListener(fd int, name string) (net.Listener, error) {
+	file := os.NewFile(uintptr(fd), name)
+
+	// Listener is created on a duplicate of the file descriptor
+	listener, err := net.FileListener(file)
+	if err != nil {
+		_ = file.Close()
+		return nil, err
+	}
+
+	if err := file.Close(); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+func closeActivatedListeners(listeners []ActivatedListener) {
+	for _, activated := range listeners {
+		_ = activated.Listener.Close()
+	}
+}
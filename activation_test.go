@@ -0,0 +1,78 @@
+package utr
+
+import (
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestActivationListenersNotActivated(t *testing.T) {
+	t.Setenv(listenPIDEnv, "")
+
+	listeners, err := ActivationListeners()
+	require.NoError(t, err)
+	require.Nil(t, listeners)
+
+	t.Setenv(listenPIDEnv, strconv.Itoa(os.Getpid()+1))
+	t.Setenv(listenFDsEnv, "1")
+
+	listeners, err = ActivationListeners()
+	require.NoError(t, err)
+	require.Nil(t, listeners)
+
+	_, exists := os.LookupEnv(listenFDsEnv)
+	require.False(t, exists)
+
+	t.Setenv(listenPIDEnv, strconv.Itoa(os.Getpid()))
+	t.Setenv(listenFDsEnv, "0")
+
+	listeners, err = ActivationListeners()
+	require.NoError(t, err)
+	require.Nil(t, listeners)
+}
+
+func TestActivationListenersBadEnv(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	envs := []map[string]string{
+		{listenPIDEnv: "pid"},
+		{listenPIDEnv: pid, listenFDsEnv: "fds"},
+		{listenPIDEnv: pid, listenFDsEnv: "-1"},
+		{listenPIDEnv: pid, listenFDsEnv: "2", listenFDNamesEnv: "first"},
+	}
+
+	for _, env := range envs {
+		for key, value := range env {
+			t.Setenv(key, value)
+		}
+
+		listeners, err := ActivationListeners()
+		require.ErrorIs(t, err, ErrActivationInvalid)
+		require.Nil(t, listeners)
+	}
+}
+
+func TestParseActivationEnv(t *testing.T) {
+	pid := strconv.Itoa(os.Getpid())
+
+	t.Setenv(listenPIDEnv, pid)
+	t.Setenv(listenFDsEnv, "2")
+
+	// Value is restored after test
+	t.Setenv(listenFDNamesEnv, "")
+	require.NoError(t, os.Unsetenv(listenFDNamesEnv))
+
+	fds, names, err := parseActivationEnv()
+	require.NoError(t, err)
+	require.Equal(t, 2, fds)
+	require.Equal(t, []string{unknownListenFDName, unknownListenFDName}, names)
+
+	t.Setenv(listenFDNamesEnv, "first:second")
+
+	fds, names, err = parseActivationEnv()
+	require.NoError(t, err)
+	require.Equal(t, 2, fds)
+	require.Equal(t, []string{"first", "second"}, names)
+}
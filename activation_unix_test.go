@@ -0,0 +1,142 @@
+//go:build unix
+
+package utr
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const activationHelperEnv = "UTR_TEST_ACTIVATION_HELPER"
+
+func TestActivationListeners(t *testing.T) {
+	names := []string{"first", "second"}
+	listeners := make([]net.Listener, 0, len(names))
+	files := make([]*os.File, 0, len(names))
+
+	defer func() {
+		for id, listener := range listeners {
+			require.NoError(t, listener.Close())
+			require.NoError(t, files[id].Close())
+		}
+	}()
+
+	var keeper Keeper
+
+	for _, name := range names {
+		socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+		listener, err := Listen(t.Context(), socketPath)
+		require.NoError(t, err)
+
+		//nolint:forcetypeassert // Listener type is determined by the network
+		file, err := listener.(*net.UnixListener).File()
+		require.NoError(t, err)
+
+		listeners = append(listeners, listener)
+		files = append(files, file)
+
+		require.NoError(t, keeper.AddPath(name, socketPath))
+	}
+
+	// LISTEN_PID must be equal to the PID of the helper process, so it is set by the
+	// shell that is replaced by the helper process
+	cmd := exec.CommandContext(
+		t.Context(),
+		"/bin/sh",
+		"-c",
+		`LISTEN_PID=$$ exec "$0" "$@"`,
+		os.Args[0],
+		"-test.run=^TestActivationHelper$",
+	)
+
+	cmd.Env = append(
+		os.Environ(),
+		activationHelperEnv+"=1",
+		listenFDsEnv+"=2",
+		listenFDNamesEnv+"=first:second",
+	)
+	cmd.ExtraFiles = files
+
+	require.NoError(t, cmd.Start())
+
+	defer func() {
+		require.NoError(t, cmd.Process.Kill())
+		require.Error(t, cmd.Wait())
+	}()
+
+	trt, err := New(&keeper, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	for _, name := range names {
+		requestURL := url.URL{
+			Scheme: DefaultSchemeHTTP,
+			Host:   name,
+		}
+
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		output, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, name, string(output))
+		require.NoError(t, resp.Body.Close())
+	}
+
+	client.CloseIdleConnections()
+}
+
+func TestActivationHelper(t *testing.T) {
+	if os.Getenv(activationHelperEnv) == "" {
+		t.Skip("helper process of the socket activation test")
+	}
+
+	listeners, err := ActivationListeners()
+	require.NoError(t, err)
+	require.Len(t, listeners, 2)
+
+	_, exists := os.LookupEnv(listenFDsEnv)
+	require.False(t, exists)
+
+	serverErr := make(chan error, len(listeners))
+
+	for _, activated := range listeners {
+		server := &http.Server{
+			Handler: http.HandlerFunc(
+				func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = io.WriteString(w, activated.Name)
+				},
+			),
+			ReadTimeout: time.Second,
+		}
+
+		go func() {
+			serverErr <- server.Serve(activated.Listener)
+		}()
+	}
+
+	// Helper process is terminated by the test process
+	require.NoError(t, <-serverErr)
+}
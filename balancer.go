@@ -0,0 +1,130 @@
+package utr
+
+import (
+	"cmp"
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// Policy of spreading of new connections across several paths to Unix domain sockets
+// of a hostname.
+//
+// Policy determines the path that is dialed first, the remaining paths are dialed in
+// turn if dialing of the previous ones fails.
+type Balancing int
+
+const (
+	// Paths are selected in turn.
+	BalancingRoundRobin Balancing = iota
+	// Paths are selected randomly.
+	BalancingRandom
+	// Path with the least number of open connections established by the transport
+	// is selected, among equal ones the first is selected.
+	BalancingLeastConnections
+)
+
+// Sets policy of spreading of new connections across several paths to Unix domain
+// sockets of a hostname.
+//
+// If policy is not set, then [BalancingRoundRobin] will be used.
+func WithBalancing(balancing Balancing) Adjuster {
+	adj := func(trt *Transport) error {
+		switch balancing {
+		case BalancingRoundRobin, BalancingRandom, BalancingLeastConnections:
+		default:
+			return fmt.Errorf("%w: %d", ErrBalancingInvalid, balancing)
+		}
+
+		trt.balancer.balancing = balancing
+
+		return nil
+	}
+
+	return adj
+}
+
+// Selects paths to Unix domain sockets according to the balancing policy.
+type balancer struct {
+	balancing Balancing
+
+	// Counters of round robin by hostnames
+	turns sync.Map
+	// Counters of open connections by paths
+	conns sync.Map
+}
+
+// Returns paths in the order in which they should be dialed.
+func (blc *balancer) order(hostname string, paths []string) []string {
+	if len(paths) == 1 {
+		return paths
+	}
+
+	switch blc.balancing {
+	case BalancingRandom:
+		return rotate(paths, rand.Uint64())
+	case BalancingLeastConnections:
+		return blc.orderLeastConnections(paths)
+	case BalancingRoundRobin:
+	}
+
+	turn, _ := blc.turns.LoadOrStore(hostname, &atomic.Uint64{})
+
+	//nolint:revive,forcetypeassert // Value type is fully controlled
+	id := turn.(*atomic.Uint64).Add(1) - 1
+
+	return rotate(paths, id)
+}
+
+// Counters are loaded in advance so that sorting is not affected by concurrent
+// changes of them.
+func (blc *balancer) orderLeastConnections(paths []string) []string {
+	conns := make(map[string]int64, len(paths))
+
+	for _, path := range paths {
+		conns[path] = blc.counter(path).Load()
+	}
+
+	ordered := slices.Clone(paths)
+
+	slices.SortStableFunc(
+		ordered,
+		func(first, second string) int {
+			return cmp.Compare(conns[first], conns[second])
+		},
+	)
+
+	return ordered
+}
+
+// Counts open connection if it is required by the balancing policy. Returns function
+// that must be called on closing of the connection or nil if counting is not required.
+func (blc *balancer) acquire(path string) func() {
+	if blc.balancing != BalancingLeastConnections {
+		return nil
+	}
+
+	counter := blc.counter(path)
+	counter.Add(1)
+
+	return func() { counter.Add(-1) }
+}
+
+func (blc *balancer) counter(path string) *atomic.Int64 {
+	counter, _ := blc.conns.LoadOrStore(path, &atomic.Int64{})
+
+	//nolint:revive,forcetypeassert // Value type is fully controlled
+	return counter.(*atomic.Int64)
+}
+
+func rotate(paths []string, shift uint64) []string {
+	start := shift % uint64(len(paths))
+	rotated := make([]string, 0, len(paths))
+
+	rotated = append(rotated, paths[start:]...)
+	rotated = append(rotated, paths[:start]...)
+
+	return rotated
+}
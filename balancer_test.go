@@ -0,0 +1,218 @@
+package utr
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithBalancing(t *testing.T) {
+	trt := &Transport{}
+
+	require.Error(t, WithBalancing(BalancingLeastConnections+1)(trt))
+	require.Error(t, WithBalancing(-1)(trt))
+	require.Equal(t, BalancingRoundRobin, trt.balancer.balancing)
+
+	require.NoError(t, WithBalancing(BalancingRandom)(trt))
+	require.Equal(t, BalancingRandom, trt.balancer.balancing)
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	var blc balancer
+
+	paths := []string{"first.sock", "second.sock", "third.sock"}
+
+	for range 2 {
+		require.Equal(t, paths, blc.order(testHostname, paths))
+		require.Equal(
+			t,
+			[]string{"second.sock", "third.sock", "first.sock"},
+			blc.order(testHostname, paths),
+		)
+		require.Equal(
+			t,
+			[]string{"third.sock", "first.sock", "second.sock"},
+			blc.order(testHostname, paths),
+		)
+	}
+
+	require.Equal(t, paths, blc.order("other", paths))
+	require.Equal(t, []string{"single.sock"}, blc.order(testHostname, []string{"single.sock"}))
+	require.Equal(t, paths, blc.order(testHostname, paths))
+	require.Equal(t, []string{"first.sock", "second.sock", "third.sock"}, paths)
+}
+
+func TestBalancerRandom(t *testing.T) {
+	blc := balancer{
+		balancing: BalancingRandom,
+	}
+
+	paths := []string{"first.sock", "second.sock", "third.sock"}
+	picked := make(map[string]int)
+
+	for range 1000 {
+		ordered := blc.order(testHostname, paths)
+		require.ElementsMatch(t, paths, ordered)
+
+		picked[ordered[0]]++
+	}
+
+	require.Len(t, picked, len(paths))
+}
+
+func TestBalancerLeastConnections(t *testing.T) {
+	blc := balancer{
+		balancing: BalancingLeastConnections,
+	}
+
+	paths := []string{"first.sock", "second.sock", "third.sock"}
+	conns := make([]net.Conn, 0, len(paths))
+
+	for _, expected := range paths {
+		path := blc.order(testHostname, paths)[0]
+		require.Equal(t, expected, path)
+
+		client, server := net.Pipe()
+		require.NoError(t, server.Close())
+
+		conns = append(conns, newTrackedConn(client, path, blc.acquire(path)))
+	}
+
+	require.Equal(t, paths, blc.order(testHostname, paths))
+
+	require.NoError(t, conns[1].Close())
+	require.NoError(t, conns[1].Close())
+	require.Equal(
+		t,
+		[]string{"second.sock", "first.sock", "third.sock"},
+		blc.order(testHostname, paths),
+	)
+	require.Equal(t, int64(0), blc.counter("second.sock").Load())
+
+	require.NoError(t, conns[0].Close())
+	require.NoError(t, conns[2].Close())
+}
+
+func TestBalancerAcquire(t *testing.T) {
+	var blc balancer
+
+	require.Nil(t, blc.acquire(testSocketPath))
+}
+
+func TestTransportBalancing(t *testing.T) {
+	for _, balancing := range []Balancing{
+		BalancingRoundRobin,
+		BalancingRandom,
+		BalancingLeastConnections,
+	} {
+		testTransportBalancingBase(t, balancing)
+	}
+}
+
+func testTransportBalancingBase(t *testing.T, balancing Balancing) {
+	const replicas = 3
+
+	var (
+		mutex   sync.Mutex
+		served  = make(map[string]int)
+		servers = make([]*http.Server, 0, replicas)
+		errs    = make([]chan error, 0, replicas)
+		paths   = make([]string, 0, replicas)
+	)
+
+	defer func() {
+		for id, server := range servers {
+			require.NoError(t, server.Shutdown(t.Context()))
+			require.Equal(t, http.ErrServerClosed, <-errs[id])
+		}
+	}()
+
+	for id := range replicas {
+		name := strconv.Itoa(id)
+		socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+		listener, err := Listen(t.Context(), socketPath)
+		require.NoError(t, err)
+
+		server := &http.Server{
+			Handler: http.HandlerFunc(
+				func(w http.ResponseWriter, _ *http.Request) {
+					mutex.Lock()
+					served[name]++
+					mutex.Unlock()
+
+					_, _ = w.Write([]byte(name))
+				},
+			),
+			ReadTimeout: time.Second,
+		}
+
+		serverErr := make(chan error, 1)
+
+		go func() {
+			serverErr <- server.Serve(listener)
+		}()
+
+		servers = append(servers, server)
+		errs = append(errs, serverErr)
+		paths = append(paths, socketPath)
+	}
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPaths(testHostname, paths...))
+
+	trt, err := New(&keeper, cloneDefaultHTTPTransport(t), WithBalancing(balancing))
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   testHostname,
+	}
+
+	// Responses are kept unread so that each request requires a new connection
+	responses := make([]*http.Response, 0, replicas)
+
+	defer func() {
+		for _, resp := range responses {
+			require.NoError(t, resp.Body.Close())
+		}
+	}()
+
+	for range replicas {
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+
+		responses = append(responses, resp)
+
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+
+	if balancing == BalancingRandom {
+		return
+	}
+
+	require.Equal(t, map[string]int{"0": 1, "1": 1, "2": 1}, served)
+}
@@ -0,0 +1,295 @@
+package utr
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Minimum number of entries in the cache at which expired entries are removed.
+const minCacheSweepThreshold = 64
+
+// Statistics of the caching resolver.
+type CacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+// Caches results of resolving of paths to Unix domain sockets by hostnames with another
+// resolver.
+//
+// Resolved paths are cached for the TTL, [ErrPathNotFound] errors are cached for the
+// negative TTL, other errors are not cached. Concurrent lookups of the same hostname
+// that are not found in the cache are collapsed into a single lookup.
+//
+// If the resolver implements the [Notifier] interface, then cached paths are
+// invalidated when mappings are removed from it. In this case the cache should be
+// released using [Cache.Close] method when it is no longer needed, otherwise
+// the resolver keeps reference to it.
+type Cache struct {
+	negativeTTL time.Duration
+	now         func() time.Time
+	resolver    Resolver
+	ttl         time.Duration
+	unsubscribe func()
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+
+	mutex          sync.Mutex
+	entries        map[string]cacheEntry
+	lookups        map[string]*cacheLookup
+	sweepThreshold int
+}
+
+type cacheEntry struct {
+	err     error
+	expires time.Time
+	paths   []string
+}
+
+type cacheLookup struct {
+	done  chan struct{}
+	err   error
+	paths []string
+	stale bool
+}
+
+// Creates new caching resolver. TTL must be positive, negative TTL must not be
+// negative, zero negative TTL disables caching of [ErrPathNotFound] errors.
+func NewCache(resolver Resolver, ttl, negativeTTL time.Duration) (*Cache, error) {
+	if resolver == nil {
+		return nil, ErrResolverEmpty
+	}
+
+	if ttl <= 0 || negativeTTL < 0 {
+		return nil, ErrTTLInvalid
+	}
+
+	cch := &Cache{
+		negativeTTL: negativeTTL,
+		now:         time.Now,
+		resolver:    resolver,
+		ttl:         ttl,
+
+		entries:        make(map[string]cacheEntry),
+		lookups:        make(map[string]*cacheLookup),
+		sweepThreshold: minCacheSweepThreshold,
+	}
+
+	if notifier, casted := resolver.(Notifier); casted {
+		cch.unsubscribe = notifier.Subscribe(cch.invalidate)
+	}
+
+	return cch, nil
+}
+
+// Resolves path to Unix domain socket by hostname.
+//
+// For hostnames resolved to several paths the first path is returned.
+func (cch *Cache) LookupPath(hostname string) (string, error) {
+	return cch.LookupPathContext(context.Background(), hostname)
+}
+
+// Resolves path to Unix domain socket by hostname with respect to the context.
+//
+// Context is passed to the resolver if it implements the [ContextResolver] or
+// [ContextMultiResolver] interfaces. If a collapsed lookup fails due to the context of
+// another caller, then lookup is repeated with the own context.
+//
+// For hostnames resolved to several paths the first path is returned.
+func (cch *Cache) LookupPathContext(ctx context.Context, hostname string) (string, error) {
+	paths, err := cch.lookup(ctx, hostname)
+	if err != nil {
+		return "", err
+	}
+
+	return paths[0], nil
+}
+
+// Resolves all paths to Unix domain sockets by hostname.
+func (cch *Cache) LookupPaths(hostname string) ([]string, error) {
+	return cch.LookupPathsContext(context.Background(), hostname)
+}
+
+// Resolves all paths to Unix domain sockets by hostname with respect to the context.
+//
+// Context is passed to the resolver in the same way as in [Cache.LookupPathContext].
+func (cch *Cache) LookupPathsContext(ctx context.Context, hostname string) ([]string, error) {
+	paths, err := cch.lookup(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	return slices.Clone(paths), nil
+}
+
+// Returned paths are shared between callers, so they must not be modified.
+func (cch *Cache) lookup(ctx context.Context, hostname string) ([]string, error) {
+	for {
+		lookup, leader, paths, err := cch.begin(hostname)
+		if lookup == nil {
+			return paths, err
+		}
+
+		if leader {
+			return cch.resolve(ctx, hostname, lookup)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-lookup.done:
+		}
+
+		if isContextError(lookup.err) && ctx.Err() == nil {
+			continue
+		}
+
+		return lookup.paths, lookup.err
+	}
+}
+
+// Subscribes to removal of mappings of hostnames and paths to Unix domain sockets in
+// the resolver, if it implements the [Notifier] interface.
+//
+// Cached paths are invalidated before the subscriber is called. Returns function that
+// cancels the subscription.
+func (cch *Cache) Subscribe(subscriber func(hostname, path string)) func() {
+	if notifier, casted := cch.resolver.(Notifier); casted {
+		return notifier.Subscribe(subscriber)
+	}
+
+	return func() {}
+}
+
+// Cancels subscription of the cache to the resolver, if it implements the [Notifier]
+// interface, so the cache can be released while the resolver is still used.
+//
+// After closing cached paths are no longer invalidated on removal of mappings.
+func (cch *Cache) Close() {
+	if cch.unsubscribe != nil {
+		cch.unsubscribe()
+	}
+}
+
+// Returns statistics of the caching resolver.
+func (cch *Cache) Stats() CacheStats {
+	stats := CacheStats{
+		Hits:   cch.hits.Load(),
+		Misses: cch.misses.Load(),
+	}
+
+	return stats
+}
+
+// Returns either the cached result or the lookup in progress and whether the caller
+// must perform it.
+func (cch *Cache) begin(hostname string) (*cacheLookup, bool, []string, error) {
+	cch.mutex.Lock()
+	defer cch.mutex.Unlock()
+
+	if entry, exists := cch.entries[hostname]; exists {
+		if cch.now().Before(entry.expires) {
+			cch.hits.Add(1)
+			return nil, false, entry.paths, entry.err
+		}
+
+		delete(cch.entries, hostname)
+	}
+
+	cch.misses.Add(1)
+
+	if lookup, exists := cch.lookups[hostname]; exists {
+		return lookup, false, nil, nil
+	}
+
+	lookup := &cacheLookup{
+		done: make(chan struct{}),
+	}
+
+	cch.lookups[hostname] = lookup
+
+	return lookup, true, nil, nil
+}
+
+func (cch *Cache) resolve(
+	ctx context.Context,
+	hostname string,
+	lookup *cacheLookup,
+) ([]string, error) {
+	completed := false
+
+	// Waiters must be released even if the resolver panics, panic itself is
+	// propagated to the caller as is
+	defer func() {
+		if completed {
+			return
+		}
+
+		cch.mutex.Lock()
+		defer cch.mutex.Unlock()
+
+		delete(cch.lookups, hostname)
+
+		lookup.err = ErrResolverPanicked
+		close(lookup.done)
+	}()
+
+	lookup.paths, lookup.err = lookupPaths(ctx, cch.resolver, hostname)
+	completed = true
+
+	cch.mutex.Lock()
+	defer cch.mutex.Unlock()
+
+	delete(cch.lookups, hostname)
+	close(lookup.done)
+
+	switch {
+	case lookup.stale:
+		// Mapping was removed during the lookup, so its result can be outdated
+	case lookup.err == nil:
+		cch.store(hostname, cacheEntry{paths: lookup.paths}, cch.ttl)
+	case errors.Is(lookup.err, ErrPathNotFound) && cch.negativeTTL > 0:
+		cch.store(hostname, cacheEntry{err: lookup.err}, cch.negativeTTL)
+	}
+
+	return lookup.paths, lookup.err
+}
+
+func (cch *Cache) store(hostname string, entry cacheEntry, ttl time.Duration) {
+	now := cch.now()
+
+	entry.expires = now.Add(ttl)
+	cch.entries[hostname] = entry
+
+	if len(cch.entries) < cch.sweepThreshold {
+		return
+	}
+
+	for key, entry := range cch.entries {
+		if !now.Before(entry.expires) {
+			delete(cch.entries, key)
+		}
+	}
+
+	cch.sweepThreshold = max(minCacheSweepThreshold, 2*len(cch.entries))
+}
+
+func (cch *Cache) invalidate(hostname, _ string) {
+	cch.mutex.Lock()
+	defer cch.mutex.Unlock()
+
+	delete(cch.entries, hostname)
+
+	if lookup, exists := cch.lookups[hostname]; exists {
+		lookup.stale = true
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
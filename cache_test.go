@@ -0,0 +1,423 @@
+package utr
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingResolver struct {
+	calls    atomic.Int64
+	gate     chan struct{}
+	resolver Resolver
+}
+
+func (rsv *countingResolver) LookupPath(hostname string) (string, error) {
+	return rsv.LookupPathContext(context.Background(), hostname)
+}
+
+func (rsv *countingResolver) LookupPathContext(
+	ctx context.Context,
+	hostname string,
+) (string, error) {
+	rsv.calls.Add(1)
+
+	if rsv.gate != nil {
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-rsv.gate:
+		}
+	}
+
+	return rsv.resolver.LookupPath(hostname)
+}
+
+type testClock struct {
+	mutex sync.Mutex
+	now   time.Time
+}
+
+func newTestClock() *testClock {
+	return &testClock{now: time.Now()}
+}
+
+func (clk *testClock) Now() time.Time {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+
+	return clk.now
+}
+
+func (clk *testClock) Advance(duration time.Duration) {
+	clk.mutex.Lock()
+	defer clk.mutex.Unlock()
+
+	clk.now = clk.now.Add(duration)
+}
+
+func TestNewCacheBad(t *testing.T) {
+	cache, err := NewCache(nil, time.Second, time.Second)
+	require.ErrorIs(t, err, ErrResolverEmpty)
+	require.Nil(t, cache)
+
+	cache, err = NewCache(&Keeper{}, 0, time.Second)
+	require.ErrorIs(t, err, ErrTTLInvalid)
+	require.Nil(t, cache)
+
+	cache, err = NewCache(&Keeper{}, time.Second, -time.Second)
+	require.ErrorIs(t, err, ErrTTLInvalid)
+	require.Nil(t, cache)
+}
+
+func TestCache(t *testing.T) {
+	const ttl = time.Minute
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+
+	resolver := &countingResolver{resolver: &keeper}
+
+	cache, err := NewCache(resolver, ttl, ttl)
+	require.NoError(t, err)
+
+	clock := newTestClock()
+	cache.now = clock.Now
+
+	for range 3 {
+		testCachePath(t, cache, testHostname, testSocketPath)
+
+		path, err := cache.LookupPath("nonexistent")
+		require.ErrorIs(t, err, ErrPathNotFound)
+		require.Empty(t, path)
+	}
+
+	require.Equal(t, int64(2), resolver.calls.Load())
+	require.Equal(t, CacheStats{Hits: 4, Misses: 2}, cache.Stats())
+
+	clock.Advance(ttl - time.Nanosecond)
+
+	testCachePath(t, cache, testHostname, testSocketPath)
+	require.Equal(t, int64(2), resolver.calls.Load())
+	require.Equal(t, CacheStats{Hits: 5, Misses: 2}, cache.Stats())
+
+	clock.Advance(time.Nanosecond)
+
+	testCachePath(t, cache, testHostname, testSocketPath)
+	require.Equal(t, int64(3), resolver.calls.Load())
+	require.Equal(t, CacheStats{Hits: 5, Misses: 3}, cache.Stats())
+}
+
+func TestCachePaths(t *testing.T) {
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPaths(testHostname, "first.sock", "second.sock"))
+
+	cache, err := NewCache(&keeper, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	paths, err := cache.LookupPaths(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first.sock", "second.sock"}, paths)
+
+	paths[0] = "modified.sock"
+
+	paths, err = cache.LookupPathsContext(t.Context(), testHostname)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first.sock", "second.sock"}, paths)
+
+	testCachePath(t, cache, testHostname, "first.sock")
+	require.Equal(t, CacheStats{Hits: 2, Misses: 1}, cache.Stats())
+
+	require.NoError(t, keeper.ReplacePaths(testHostname, "second.sock"))
+
+	paths, err = cache.LookupPaths(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, []string{"second.sock"}, paths)
+}
+
+func TestCacheNoNegative(t *testing.T) {
+	resolver := &countingResolver{resolver: errResolver{}}
+
+	cache, err := NewCache(resolver, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	for range 3 {
+		path, err := cache.LookupPath(testHostname)
+		require.ErrorIs(t, err, errTestResolver)
+		require.Empty(t, path)
+	}
+
+	require.Equal(t, int64(3), resolver.calls.Load())
+
+	resolver = &countingResolver{resolver: &Keeper{}}
+
+	cache, err = NewCache(resolver, time.Minute, 0)
+	require.NoError(t, err)
+
+	for range 3 {
+		path, err := cache.LookupPath(testHostname)
+		require.ErrorIs(t, err, ErrPathNotFound)
+		require.Empty(t, path)
+	}
+
+	require.Equal(t, int64(3), resolver.calls.Load())
+}
+
+func TestCacheInvalidate(t *testing.T) {
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+
+	cache, err := NewCache(&keeper, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	var removed []string
+
+	cache.Subscribe(
+		func(_, path string) {
+			testCachePath(t, cache, testHostname, "other.sock")
+
+			removed = append(removed, path)
+		},
+	)
+
+	testCachePath(t, cache, testHostname, testSocketPath)
+
+	require.NoError(t, keeper.ReplacePath(testHostname, "other.sock"))
+	require.Equal(t, []string{testSocketPath}, removed)
+
+	testCachePath(t, cache, testHostname, "other.sock")
+}
+
+func TestCacheClose(t *testing.T) {
+	var keeper Keeper
+
+	cache, err := NewCache(&keeper, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	unsubscribe := cache.Subscribe(func(string, string) {})
+	require.Len(t, keeper.notifier.subscriptions, 2)
+
+	unsubscribe()
+	require.Len(t, keeper.notifier.subscriptions, 1)
+
+	cache.Close()
+	require.Empty(t, keeper.notifier.subscriptions)
+
+	cache, err = NewCache(Decoder{}, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	cache.Subscribe(func(string, string) {})()
+	cache.Close()
+}
+
+func TestCacheCollapse(t *testing.T) {
+	const lookups = 10
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+
+	resolver := &countingResolver{
+		gate:     make(chan struct{}),
+		resolver: &keeper,
+	}
+
+	cache, err := NewCache(resolver, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	var wg sync.WaitGroup
+
+	for range lookups {
+		wg.Go(func() { testCachePath(t, cache, testHostname, testSocketPath) })
+	}
+
+	require.Eventually(
+		t,
+		func() bool { return cache.Stats().Misses == lookups },
+		time.Second,
+		time.Millisecond,
+	)
+
+	close(resolver.gate)
+	wg.Wait()
+
+	require.Equal(t, int64(1), resolver.calls.Load())
+}
+
+func TestCacheCollapseCanceled(t *testing.T) {
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+
+	resolver := &countingResolver{
+		gate:     make(chan struct{}),
+		resolver: &keeper,
+	}
+
+	cache, err := NewCache(resolver, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	leaderErr := make(chan error, 1)
+
+	go func() {
+		_, err := cache.LookupPathContext(ctx, testHostname)
+		leaderErr <- err
+	}()
+
+	require.Eventually(
+		t,
+		func() bool { return resolver.calls.Load() == 1 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	followerDone := make(chan struct{})
+
+	go func() {
+		defer close(followerDone)
+
+		testCachePath(t, cache, testHostname, testSocketPath)
+	}()
+
+	require.Eventually(
+		t,
+		func() bool { return cache.Stats().Misses == 2 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	cancel()
+	require.ErrorIs(t, <-leaderErr, context.Canceled)
+
+	require.Eventually(
+		t,
+		func() bool { return resolver.calls.Load() == 2 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	close(resolver.gate)
+	<-followerDone
+
+	ctx, cancel = context.WithCancel(t.Context())
+	cancel()
+
+	// Path is cached, so context is not checked
+	path, err := cache.LookupPathContext(ctx, testHostname)
+	require.NoError(t, err)
+	require.Equal(t, testSocketPath, path)
+}
+
+type panickingResolver struct {
+	gate   chan struct{}
+	panics atomic.Bool
+}
+
+func (rsv *panickingResolver) LookupPath(string) (string, error) {
+	<-rsv.gate
+
+	if rsv.panics.Load() {
+		panic("resolver failure")
+	}
+
+	return testSocketPath, nil
+}
+
+func TestCachePanic(t *testing.T) {
+	resolver := &panickingResolver{
+		gate: make(chan struct{}),
+	}
+
+	resolver.panics.Store(true)
+
+	cache, err := NewCache(resolver, time.Minute, time.Minute)
+	require.NoError(t, err)
+
+	leaderPanic := make(chan any, 1)
+
+	go func() {
+		defer func() {
+			leaderPanic <- recover()
+		}()
+
+		_, _ = cache.LookupPath(testHostname)
+	}()
+
+	require.Eventually(
+		t,
+		func() bool { return cache.Stats().Misses == 1 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	followerErr := make(chan error, 1)
+
+	go func() {
+		_, err := cache.LookupPath(testHostname)
+		followerErr <- err
+	}()
+
+	require.Eventually(
+		t,
+		func() bool { return cache.Stats().Misses == 2 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	close(resolver.gate)
+
+	require.Equal(t, "resolver failure", <-leaderPanic)
+	require.ErrorIs(t, <-followerErr, ErrResolverPanicked)
+	require.Empty(t, cache.lookups)
+
+	resolver.panics.Store(false)
+
+	testCachePath(t, cache, testHostname, testSocketPath)
+}
+
+func TestCacheSweep(t *testing.T) {
+	const ttl = time.Minute
+
+	var keeper Keeper
+
+	for id := range minCacheSweepThreshold {
+		require.NoError(t, keeper.AddPath(testHostname+strconv.Itoa(id), testSocketPath))
+	}
+
+	cache, err := NewCache(&keeper, ttl, ttl)
+	require.NoError(t, err)
+
+	clock := newTestClock()
+	cache.now = clock.Now
+
+	for id := range minCacheSweepThreshold - 1 {
+		testCachePath(t, cache, testHostname+strconv.Itoa(id), testSocketPath)
+	}
+
+	clock.Advance(ttl)
+
+	testCachePath(t, cache, testHostname+strconv.Itoa(minCacheSweepThreshold-1), testSocketPath)
+
+	cache.mutex.Lock()
+	defer cache.mutex.Unlock()
+
+	require.Len(t, cache.entries, 1)
+	require.Equal(t, minCacheSweepThreshold, cache.sweepThreshold)
+}
+
+func testCachePath(t *testing.T, cache *Cache, hostname, expected string) {
+	path, err := cache.LookupPath(hostname)
+	require.NoError(t, err)
+	require.Equal(t, expected, path)
+}
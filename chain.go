@@ -0,0 +1,145 @@
+package utr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Error of resolving of path to Unix domain socket by a chain of resolvers. Consulted
+// contains resolvers that were asked, in order. Err contains the error of the last
+// consulted resolver.
+type ChainError struct {
+	Hostname  string
+	Consulted []Resolver
+	Err       error
+}
+
+func (err *ChainError) Error() string {
+	consulted := make([]string, len(err.Consulted))
+
+	for id, resolver := range err.Consulted {
+		consulted[id] = fmt.Sprintf("#%d %T", id, resolver)
+	}
+
+	return fmt.Sprintf(
+		"resolving of hostname %q by resolvers [%s] failed: %v",
+		err.Hostname,
+		strings.Join(consulted, ", "),
+		err.Err,
+	)
+}
+
+func (err *ChainError) Unwrap() error {
+	return err.Err
+}
+
+// Resolves paths to Unix domain sockets by hostnames asking several resolvers in order.
+//
+// Next resolver is asked only if the previous one returns [ErrPathNotFound], other
+// errors are returned immediately. Errors are returned as [ChainError].
+type Chain struct {
+	resolvers []Resolver
+}
+
+// Creates new chain of resolvers. At least one resolver must be specified.
+func NewChain(resolvers ...Resolver) (*Chain, error) {
+	if len(resolvers) == 0 {
+		return nil, ErrResolverEmpty
+	}
+
+	for id, resolver := range resolvers {
+		if resolver == nil {
+			return nil, fmt.Errorf("%w: #%d", ErrResolverEmpty, id)
+		}
+	}
+
+	chn := &Chain{
+		resolvers: resolvers,
+	}
+
+	return chn, nil
+}
+
+// Resolves path to Unix domain socket by hostname.
+//
+// For hostnames resolved to several paths the first path is returned.
+func (chn *Chain) LookupPath(hostname string) (string, error) {
+	return chn.LookupPathContext(context.Background(), hostname)
+}
+
+// Resolves path to Unix domain socket by hostname with respect to the context.
+//
+// Context is passed to resolvers that implement the [ContextResolver] or
+// [ContextMultiResolver] interfaces.
+//
+// For hostnames resolved to several paths the first path is returned.
+func (chn *Chain) LookupPathContext(ctx context.Context, hostname string) (string, error) {
+	paths, err := chn.LookupPathsContext(ctx, hostname)
+	if err != nil {
+		return "", err
+	}
+
+	return paths[0], nil
+}
+
+// Resolves all paths to Unix domain sockets by hostname.
+func (chn *Chain) LookupPaths(hostname string) ([]string, error) {
+	return chn.LookupPathsContext(context.Background(), hostname)
+}
+
+// Resolves all paths to Unix domain sockets by hostname with respect to the context.
+//
+// Context is passed to resolvers that implement the [ContextResolver] or
+// [ContextMultiResolver] interfaces.
+func (chn *Chain) LookupPathsContext(ctx context.Context, hostname string) ([]string, error) {
+	var err error
+
+	for id, resolver := range chn.resolvers {
+		var paths []string
+
+		paths, err = lookupPaths(ctx, resolver, hostname)
+		if err == nil {
+			return paths, nil
+		}
+
+		if !errors.Is(err, ErrPathNotFound) {
+			return nil, chn.error(hostname, id, err)
+		}
+	}
+
+	return nil, chn.error(hostname, len(chn.resolvers)-1, err)
+}
+
+func (chn *Chain) error(hostname string, last int, err error) error {
+	chainErr := &ChainError{
+		Hostname:  hostname,
+		Consulted: slices.Clone(chn.resolvers[:last+1]),
+		Err:       err,
+	}
+
+	return chainErr
+}
+
+// Subscribes to removal of mappings of hostnames and paths to Unix domain sockets in
+// all resolvers of the chain that implement the [Notifier] interface. Returns function
+// that cancels the subscriptions.
+func (chn *Chain) Subscribe(subscriber func(hostname, path string)) func() {
+	unsubscribes := make([]func(), 0, len(chn.resolvers))
+
+	for _, resolver := range chn.resolvers {
+		if notifier, casted := resolver.(Notifier); casted {
+			unsubscribes = append(unsubscribes, notifier.Subscribe(subscriber))
+		}
+	}
+
+	unsubscribe := func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+
+	return unsubscribe
+}
@@ -0,0 +1,134 @@
+package utr
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var errTestResolver = errors.New("test resolver error")
+
+type errResolver struct{}
+
+func (errResolver) LookupPath(string) (string, error) {
+	return "", errTestResolver
+}
+
+func TestNewChainBad(t *testing.T) {
+	chain, err := NewChain()
+	require.ErrorIs(t, err, ErrResolverEmpty)
+	require.Nil(t, chain)
+
+	chain, err = NewChain(&Keeper{}, nil)
+	require.ErrorIs(t, err, ErrResolverEmpty)
+	require.Nil(t, chain)
+}
+
+func TestChain(t *testing.T) {
+	var first, second Keeper
+
+	require.NoError(t, first.AddPath("first", "first.sock"))
+	require.NoError(t, first.AddPath(testHostname, "first-service.sock"))
+	require.NoError(t, second.AddPath(testHostname, "second-service.sock"))
+	require.NoError(t, second.AddPath("second", "second.sock"))
+
+	chain, err := NewChain(&first, &second, errResolver{}, Decoder{})
+	require.NoError(t, err)
+
+	testChainPath(t, chain, "first", "first.sock")
+	testChainPath(t, chain, testHostname, "first-service.sock")
+	testChainPath(t, chain, "second", "second.sock")
+
+	path, err := chain.LookupPath("nonexistent")
+	require.ErrorIs(t, err, errTestResolver)
+	require.Empty(t, path)
+
+	var chainErr *ChainError
+
+	require.ErrorAs(t, err, &chainErr)
+	require.Equal(t, "nonexistent", chainErr.Hostname)
+	require.Equal(t, []Resolver{&first, &second, errResolver{}}, chainErr.Consulted)
+	require.Equal(
+		t,
+		`resolving of hostname "nonexistent" by resolvers [#0 *utr.Keeper, #1 *utr.Keeper, `+
+			`#2 utr.errResolver] failed: test resolver error`,
+		chainErr.Error(),
+	)
+
+	chain, err = NewChain(&first, &second)
+	require.NoError(t, err)
+
+	path, err = chain.LookupPath("nonexistent")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.ErrorAs(t, err, &chainErr)
+	require.Len(t, chainErr.Consulted, 2)
+	require.Empty(t, path)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	path, err = chain.LookupPathContext(ctx, "second")
+	require.ErrorIs(t, err, context.Canceled)
+	require.ErrorAs(t, err, &chainErr)
+	require.Len(t, chainErr.Consulted, 1)
+	require.Empty(t, path)
+}
+
+func TestChainPaths(t *testing.T) {
+	var first, second Keeper
+
+	require.NoError(t, first.AddPath("first", "first.sock"))
+	require.NoError(t, second.AddPaths(testHostname, "first.sock", "second.sock"))
+
+	chain, err := NewChain(&first, &second)
+	require.NoError(t, err)
+
+	paths, err := chain.LookupPaths("first")
+	require.NoError(t, err)
+	require.Equal(t, []string{"first.sock"}, paths)
+
+	paths, err = chain.LookupPaths(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first.sock", "second.sock"}, paths)
+
+	testChainPath(t, chain, testHostname, "first.sock")
+
+	paths, err = chain.LookupPaths("nonexistent")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Nil(t, paths)
+}
+
+func TestChainSubscribe(t *testing.T) {
+	var first, second Keeper
+
+	require.NoError(t, first.AddPath(testHostname, "first.sock"))
+	require.NoError(t, second.AddPath(testHostname, "second.sock"))
+
+	chain, err := NewChain(&first, Decoder{}, &second)
+	require.NoError(t, err)
+
+	var removed []string
+
+	unsubscribe := chain.Subscribe(
+		func(_, path string) {
+			removed = append(removed, path)
+		},
+	)
+
+	require.NoError(t, first.RemovePath(testHostname))
+	require.NoError(t, second.RemovePath(testHostname))
+	require.Equal(t, []string{"first.sock", "second.sock"}, removed)
+
+	unsubscribe()
+
+	require.Empty(t, first.notifier.subscriptions)
+	require.Empty(t, second.notifier.subscriptions)
+}
+
+func testChainPath(t *testing.T, chain *Chain, hostname, expected string) {
+	path, err := chain.LookupPath(hostname)
+	require.NoError(t, err)
+	require.Equal(t, expected, path)
+}
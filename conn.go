@@ -0,0 +1,132 @@
+package utr
+
+import (
+	"net"
+	"sync"
+	"sync/atomic"
+)
+
+// Connection to Unix domain socket whose path, generation of TLS configuration,
+// peer credentials and closing are tracked by the transport.
+type trackedConn struct {
+	net.Conn
+
+	generation uint64
+	once       sync.Once
+	path       string
+	peer       *peerResult
+	release    func()
+
+	// Connection is retired, so it must not be reused
+	retired atomic.Bool
+	// Number of requests the connection is obtained for, decreased when
+	// the connection is returned to the pool of idle connections
+	uses atomic.Int64
+}
+
+// Release function can be nil.
+func newTrackedConn(conn net.Conn, path string, release func()) *trackedConn {
+	tracked := &trackedConn{
+		Conn:    conn,
+		path:    path,
+		release: release,
+	}
+
+	// Connection is dialed for a request
+	tracked.uses.Store(1)
+
+	return tracked
+}
+
+func (cn *trackedConn) Close() error {
+	err := cn.Conn.Close()
+
+	if cn.release != nil {
+		cn.once.Do(cn.release)
+	}
+
+	return err
+}
+
+// Returns the underlying connection like the [tls.Conn.NetConn].
+func (cn *trackedConn) NetConn() net.Conn {
+	return cn.Conn
+}
+
+// Connection can be wrapped by the [tls.Conn].
+func getTrackedConn(conn net.Conn) *trackedConn {
+	for {
+		if tracked, casted := conn.(*trackedConn); casted {
+			return tracked
+		}
+
+		wrapper, casted := conn.(interface{ NetConn() net.Conn })
+		if !casted {
+			return nil
+		}
+
+		conn = wrapper.NetConn()
+	}
+}
+
+// Keeps tracked connections by paths to Unix domain sockets to retire connections to
+// paths that must no longer be used.
+type connRegistry struct {
+	mutex sync.Mutex
+	paths map[string]map[*trackedConn]struct{}
+}
+
+func newConnRegistry() *connRegistry {
+	reg := &connRegistry{
+		paths: make(map[string]map[*trackedConn]struct{}),
+	}
+
+	return reg
+}
+
+func (reg *connRegistry) add(conn *trackedConn) {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+
+	conns := reg.paths[conn.path]
+	if conns == nil {
+		conns = make(map[*trackedConn]struct{})
+		reg.paths[conn.path] = conns
+	}
+
+	conns[conn] = struct{}{}
+}
+
+func (reg *connRegistry) delete(conn *trackedConn) {
+	reg.mutex.Lock()
+	defer reg.mutex.Unlock()
+
+	delete(reg.paths[conn.path], conn)
+
+	if len(reg.paths[conn.path]) == 0 {
+		delete(reg.paths, conn.path)
+	}
+}
+
+// Marks connections to the path as retired and closes idle ones, connections that are
+// in use are closed when they are returned to the pool of idle connections or are
+// obtained for the next request.
+func (reg *connRegistry) retire(path string) {
+	reg.mutex.Lock()
+
+	conns := make([]*trackedConn, 0, len(reg.paths[path]))
+
+	for conn := range reg.paths[path] {
+		conns = append(conns, conn)
+	}
+
+	reg.mutex.Unlock()
+
+	for _, conn := range conns {
+		conn.retired.Store(true)
+
+		if conn.uses.Load() == 0 {
+			_ = conn.Close()
+		}
+	}
+}
@@ -0,0 +1,203 @@
+package utr
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptrace"
+)
+
+type (
+	peerHookKey   struct{}
+	peerResultKey struct{}
+)
+
+// Credentials obtained right after dialing, since dial middlewares can wrap
+// the connection so that they cannot be obtained from it later.
+type peerResult struct {
+	peer Credentials
+	err  error
+}
+
+// Credentials of the process that listens Unix domain socket.
+type Credentials struct {
+	PID int
+	UID int
+	GID int
+}
+
+// Error of verification of credentials of the process that listens Unix domain socket
+// with path resolved by hostname. Err contains the reason of the verification failure.
+type PeerError struct {
+	Hostname string
+	Path     string
+	Peer     Credentials
+	Err      error
+}
+
+func (err *PeerError) Error() string {
+	return fmt.Sprintf(
+		"verification of peer (pid %d, uid %d, gid %d) of hostname %q with path %q failed: %v",
+		err.Peer.PID,
+		err.Peer.UID,
+		err.Peer.GID,
+		err.Hostname,
+		err.Path,
+		err.Err,
+	)
+}
+
+func (err *PeerError) Unwrap() error {
+	return err.Err
+}
+
+// Sets function that verifies credentials of the process that listens Unix domain
+// socket right after the connection is established.
+//
+// If function returns an error, then the connection is closed and establishing of
+// connection fails with [PeerError] that wraps the returned error.
+//
+// Obtaining of credentials is supported only on Linux, on other systems establishing of
+// connection fails with [PeerError] that wraps [ErrCredentialsUnsupported].
+func WithPeerVerifier(verify func(hostname string, peer Credentials) error) Adjuster {
+	adj := func(trt *Transport) error {
+		if verify == nil {
+			return ErrPeerVerifierEmpty
+		}
+
+		trt.peerVerifiers = append(trt.peerVerifiers, verify)
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets expected process ID of the process that listens Unix domain socket.
+//
+// On mismatch establishing of connection fails with [PeerError] that wraps
+// [ErrPeerMismatch].
+func WithPeerPID(pid int) Adjuster {
+	verify := func(_ string, peer Credentials) error {
+		if peer.PID != pid {
+			return fmt.Errorf("%w: pid %d, expected %d", ErrPeerMismatch, peer.PID, pid)
+		}
+
+		return nil
+	}
+
+	return WithPeerVerifier(verify)
+}
+
+// Sets expected user ID of the process that listens Unix domain socket.
+//
+// On mismatch establishing of connection fails with [PeerError] that wraps
+// [ErrPeerMismatch].
+func WithPeerUID(uid int) Adjuster {
+	verify := func(_ string, peer Credentials) error {
+		if peer.UID != uid {
+			return fmt.Errorf("%w: uid %d, expected %d", ErrPeerMismatch, peer.UID, uid)
+		}
+
+		return nil
+	}
+
+	return WithPeerVerifier(verify)
+}
+
+// Sets expected group ID of the process that listens Unix domain socket.
+//
+// On mismatch establishing of connection fails with [PeerError] that wraps
+// [ErrPeerMismatch].
+func WithPeerGID(gid int) Adjuster {
+	verify := func(_ string, peer Credentials) error {
+		if peer.GID != gid {
+			return fmt.Errorf("%w: gid %d, expected %d", ErrPeerMismatch, peer.GID, gid)
+		}
+
+		return nil
+	}
+
+	return WithPeerVerifier(verify)
+}
+
+// Returns a copy of the parent context with the hook that is called with credentials of
+// the process that listens Unix domain socket each time a connection is obtained for
+// the request made via Unix domain socket with the returned context.
+//
+// If credentials cannot be obtained, then the hook is called with an error. Obtaining
+// of credentials is supported only on Linux, on other systems the hook is called with
+// [ErrCredentialsUnsupported].
+//
+// The hook is called in the same way as hooks of the [httptrace.ClientTrace], so it
+// should not block and it can be called from different goroutines.
+func ContextWithPeerHook(
+	parent context.Context,
+	hook func(peer Credentials, err error),
+) context.Context {
+	return context.WithValue(parent, peerHookKey{}, hook)
+}
+
+func withPeerTrace(ctx context.Context) context.Context {
+	hook, _ := ctx.Value(peerHookKey{}).(func(peer Credentials, err error))
+	if hook == nil {
+		return ctx
+	}
+
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			hook(getConnPeerCredentials(info.Conn))
+		},
+	}
+
+	return httptrace.WithClientTrace(ctx, trace)
+}
+
+// Connection can be wrapped by the [tls.Conn], by the transport itself and by dial
+// middlewares.
+func getConnPeerCredentials(conn net.Conn) (Credentials, error) {
+	for {
+		if tracked, casted := conn.(*trackedConn); casted && tracked.peer != nil {
+			return tracked.peer.peer, tracked.peer.err
+		}
+
+		wrapper, casted := conn.(interface{ NetConn() net.Conn })
+		if !casted {
+			return getPeerCredentials(conn)
+		}
+
+		conn = wrapper.NetConn()
+	}
+}
+
+// Credentials are stored into the result passed in the context, if any.
+func (trt *Transport) verifyPeer(ctx context.Context, conn net.Conn, hostname, path string) error {
+	result, _ := ctx.Value(peerResultKey{}).(*peerResult)
+
+	if len(trt.peerVerifiers) == 0 && result == nil {
+		return nil
+	}
+
+	peer, err := getPeerCredentials(conn)
+
+	if result != nil {
+		result.peer = peer
+		result.err = err
+	}
+
+	if len(trt.peerVerifiers) == 0 {
+		return nil
+	}
+
+	if err != nil {
+		return &PeerError{Hostname: hostname, Path: path, Err: err}
+	}
+
+	for _, verify := range trt.peerVerifiers {
+		if err := verify(hostname, peer); err != nil {
+			return &PeerError{Hostname: hostname, Path: path, Peer: peer, Err: err}
+		}
+	}
+
+	return nil
+}
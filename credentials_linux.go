@@ -0,0 +1,47 @@
+package utr
+
+import (
+	"net"
+	"syscall"
+)
+
+func getPeerCredentials(conn net.Conn) (Credentials, error) {
+	sysConn, casted := conn.(syscall.Conn)
+	if !casted {
+		return Credentials{}, ErrCredentialsUnsupported
+	}
+
+	raw, err := sysConn.SyscallConn()
+	if err != nil {
+		return Credentials{}, err
+	}
+
+	var (
+		ucred    *syscall.Ucred
+		ucredErr error
+	)
+
+	control := func(fd uintptr) {
+		ucred, ucredErr = syscall.GetsockoptUcred(
+			int(fd),
+			syscall.SOL_SOCKET,
+			syscall.SO_PEERCRED,
+		)
+	}
+
+	if err := raw.Control(control); err != nil {
+		return Credentials{}, err
+	}
+
+	if ucredErr != nil {
+		return Credentials{}, ucredErr
+	}
+
+	creds := Credentials{
+		PID: int(ucred.Pid),
+		UID: int(ucred.Uid),
+		GID: int(ucred.Gid),
+	}
+
+	return creds, nil
+}
@@ -0,0 +1,261 @@
+package utr
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportPeerVerifier(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		),
+		ReadTimeout: time.Second,
+	}
+
+	serverErr := make(chan error)
+	defer close(serverErr)
+
+	var blank net.ListenConfig
+
+	listener, err := blank.Listen(t.Context(), unixNetworkName, socketPath)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}()
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+
+	expected := Credentials{
+		PID: os.Getpid(),
+		UID: os.Getuid(),
+		GID: os.Getgid(),
+	}
+
+	var obtained Credentials
+
+	verify := func(hostname string, peer Credentials) error {
+		require.Equal(t, testHostname, hostname)
+
+		obtained = peer
+
+		return nil
+	}
+
+	testTransportPeerVerifierBase(
+		t,
+		&keeper,
+		nil,
+		WithPeerVerifier(verify),
+		WithPeerPID(expected.PID),
+		WithPeerUID(expected.UID),
+		WithPeerGID(expected.GID),
+	)
+
+	require.Equal(t, expected, obtained)
+
+	testTransportPeerVerifierBase(t, &keeper, ErrPeerMismatch, WithPeerUID(expected.UID+1))
+}
+
+func testTransportPeerVerifierBase(
+	t *testing.T,
+	keeper *Keeper,
+	expectedErr error,
+	opts ...Adjuster,
+) {
+	trt, err := New(keeper, cloneDefaultHTTPTransport(t), opts...)
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   testHostname,
+	}
+
+	request, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodGet,
+		requestURL.String(),
+		http.NoBody,
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Do(request)
+	if expectedErr != nil {
+		var peerErr *PeerError
+
+		require.ErrorIs(t, err, expectedErr)
+		require.ErrorAs(t, err, &peerErr)
+		require.Equal(t, testHostname, peerErr.Hostname)
+		require.Nil(t, resp)
+
+		return
+	}
+
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.NoError(t, resp.Body.Close())
+
+	client.CloseIdleConnections()
+}
+
+func TestTransportPeerHook(t *testing.T) {
+	testTransportPeerHookBase(t, false, false)
+	testTransportPeerHookBase(t, true, false)
+	testTransportPeerHookBase(t, false, true)
+	testTransportPeerHookBase(t, true, true)
+}
+
+func testTransportPeerHookBase(t *testing.T, useTLS, useMiddleware bool) {
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+	caPool, serverCerts, clientCerts := genTempPKI(t, testHostname)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+		),
+		ReadTimeout: time.Second,
+	}
+
+	serverErr := make(chan error)
+	defer close(serverErr)
+
+	var blank net.ListenConfig
+
+	listener, err := blank.Listen(t.Context(), unixNetworkName, socketPath)
+	require.NoError(t, err)
+
+	if useTLS {
+		listenTLSConfig := &tls.Config{
+			Certificates: serverCerts,
+			MinVersion:   tls.VersionTLS13,
+		}
+
+		listener = tls.NewListener(listener, listenTLSConfig)
+	}
+
+	defer func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}()
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+
+	httpTransport := cloneDefaultHTTPTransport(t)
+
+	httpTransport.TLSClientConfig = &tls.Config{
+		Certificates: clientCerts,
+		MinVersion:   tls.VersionTLS13,
+		RootCAs:      caPool,
+	}
+
+	var opts []Adjuster
+
+	// Wrapper does not provide the underlying connection
+	if useMiddleware {
+		var read atomic.Int64
+
+		wrapping := func(next DialFunc) DialFunc {
+			dial := func(ctx context.Context, hostname, path string) (net.Conn, error) {
+				conn, err := next(ctx, hostname, path)
+				if err != nil {
+					return nil, err
+				}
+
+				return &countingConn{Conn: conn, read: &read}, nil
+			}
+
+			return dial
+		}
+
+		opts = append(opts, WithDialMiddleware(wrapping))
+	}
+
+	trt, err := New(&keeper, httpTransport, opts...)
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   testHostname,
+	}
+
+	if useTLS {
+		requestURL.Scheme = DefaultSchemeHTTPS
+	}
+
+	expected := Credentials{
+		PID: os.Getpid(),
+		UID: os.Getuid(),
+		GID: os.Getgid(),
+	}
+
+	for range 2 {
+		var (
+			obtained    Credentials
+			obtainedErr error
+			calls       int
+		)
+
+		hook := func(peer Credentials, err error) {
+			obtained = peer
+			obtainedErr = err
+			calls++
+		}
+
+		request, err := http.NewRequestWithContext(
+			ContextWithPeerHook(t.Context(), hook),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, resp.Body.Close())
+
+		require.NoError(t, obtainedErr)
+		require.Equal(t, expected, obtained)
+		require.Equal(t, 1, calls)
+	}
+
+	client.CloseIdleConnections()
+}
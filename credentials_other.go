@@ -0,0 +1,9 @@
+//go:build !linux
+
+package utr
+
+import "net"
+
+func getPeerCredentials(net.Conn) (Credentials, error) {
+	return Credentials{}, ErrCredentialsUnsupported
+}
@@ -0,0 +1,91 @@
+package utr
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithPeerVerifier(t *testing.T) {
+	trt := &Transport{}
+
+	require.Error(t, WithPeerVerifier(nil)(trt))
+	require.Empty(t, trt.peerVerifiers)
+
+	require.NoError(t, WithPeerVerifier(func(string, Credentials) error { return nil })(trt))
+	require.NoError(t, WithPeerPID(1)(trt))
+	require.NoError(t, WithPeerUID(1)(trt))
+	require.NoError(t, WithPeerGID(1)(trt))
+	require.Len(t, trt.peerVerifiers, 4)
+}
+
+func TestPeerVerifiers(t *testing.T) {
+	peer := Credentials{
+		PID: 1,
+		UID: 2,
+		GID: 3,
+	}
+
+	for _, adj := range []Adjuster{WithPeerPID(1), WithPeerUID(2), WithPeerGID(3)} {
+		trt := &Transport{}
+
+		require.NoError(t, adj(trt))
+		require.NoError(t, trt.peerVerifiers[0](testHostname, peer))
+	}
+
+	for _, adj := range []Adjuster{WithPeerPID(2), WithPeerUID(3), WithPeerGID(1)} {
+		trt := &Transport{}
+
+		require.NoError(t, adj(trt))
+		require.ErrorIs(t, trt.peerVerifiers[0](testHostname, peer), ErrPeerMismatch)
+	}
+}
+
+func TestPeerError(t *testing.T) {
+	err := &PeerError{
+		Hostname: testHostname,
+		Path:     testSocketPath,
+		Peer: Credentials{
+			PID: 1,
+			UID: 2,
+			GID: 3,
+		},
+		Err: ErrPeerMismatch,
+	}
+
+	require.ErrorIs(t, err, ErrPeerMismatch)
+	require.Equal(
+		t,
+		`verification of peer (pid 1, uid 2, gid 3) of hostname "service" `+
+			`with path "service.sock" failed: peer credentials do not match`,
+		err.Error(),
+	)
+}
+
+func TestContextWithPeerHook(t *testing.T) {
+	require.Equal(t, t.Context(), withPeerTrace(t.Context()))
+
+	var nilHook func(Credentials, error)
+
+	ctx := ContextWithPeerHook(t.Context(), nilHook)
+	require.Equal(t, ctx, withPeerTrace(ctx))
+
+	ctx = ContextWithPeerHook(t.Context(), func(Credentials, error) {})
+	require.NotEqual(t, ctx, withPeerTrace(ctx))
+}
+
+func TestGetConnPeerCredentials(t *testing.T) {
+	client, server := net.Pipe()
+
+	defer func() {
+		require.NoError(t, client.Close())
+	}()
+	defer func() {
+		require.NoError(t, server.Close())
+	}()
+
+	peer, err := getConnPeerCredentials(client)
+	require.ErrorIs(t, err, ErrCredentialsUnsupported)
+	require.Equal(t, Credentials{}, peer)
+}
@@ -0,0 +1,74 @@
+package utr
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Resolves path to Unix domain socket by decoding it from percent-encoded hostname,
+// e.g. %2Fvar%2Frun%2Fdocker.sock resolves to /var/run/docker.sock.
+//
+// Note that [url.Parse] does not accept percent-encoded reserved characters in the
+// host, so encoded hostname should be set directly into [url.URL.Host] or escaped once
+// more. Hostname encoded by [EncodeHostname] set into [url.URL.Host] is formatted by
+// [url.URL.String] in a form that is accepted by [url.Parse], e.g. URL string for
+// /var/run/docker.sock is http+unix://%252Fvar%252Frun%252Fdocker.sock/v1.45/info.
+type Decoder struct{}
+
+// Resolves path to Unix domain socket by decoding it from hostname.
+func (Decoder) LookupPath(hostname string) (string, error) {
+	path, err := url.PathUnescape(hostname)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrHostnameInvalid, err)
+	}
+
+	if err := isValidPath(path); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// Encodes path to Unix domain socket into hostname that is decoded by [Decoder].
+//
+// All characters except unreserved ones are percent-encoded, so hostname does not
+// contain port separator, userinfo separator and path separators.
+func EncodeHostname(path string) string {
+	const (
+		upperhex = "0123456789ABCDEF"
+
+		nibbleMask = 0x0f
+		nibbleSize = 4
+	)
+
+	var builder strings.Builder
+
+	for id := range len(path) {
+		char := path[id]
+
+		if isUnreserved(char) {
+			builder.WriteByte(char)
+			continue
+		}
+
+		builder.WriteByte('%')
+		builder.WriteByte(upperhex[char>>nibbleSize])
+		builder.WriteByte(upperhex[char&nibbleMask])
+	}
+
+	return builder.String()
+}
+
+func isUnreserved(char byte) bool {
+	switch {
+	case 'a' <= char && char <= 'z':
+		return true
+	case 'A' <= char && char <= 'Z':
+		return true
+	case '0' <= char && char <= '9':
+		return true
+	}
+
+	return char == '-' || char == '.' || char == '_' || char == '~'
+}
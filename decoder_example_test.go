@@ -0,0 +1,34 @@
+package utr_test
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/akramarenkov/utr"
+)
+
+func ExampleDecoder() {
+	// Encoded hostname is escaped once more in the string form of the URL
+	requestURL := url.URL{
+		Scheme: utr.DefaultSchemeHTTP,
+		Host:   utr.EncodeHostname("/var/run/docker.sock"),
+		Path:   "/v1.45/info",
+	}
+
+	fmt.Println(requestURL.String())
+
+	parsed, err := url.Parse("http+unix://%252Fvar%252Frun%252Fdocker.sock/v1.45/info")
+	if err != nil {
+		panic(err)
+	}
+
+	path, err := utr.Decoder{}.LookupPath(parsed.Hostname())
+	if err != nil {
+		panic(err)
+	}
+
+	fmt.Println(path)
+	// Output:
+	// http+unix://%252Fvar%252Frun%252Fdocker.sock/v1.45/info
+	// /var/run/docker.sock
+}
@@ -0,0 +1,126 @@
+package utr
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecoder(t *testing.T) {
+	var decoder Decoder
+
+	path, err := decoder.LookupPath("%2Fvar%2Frun%2Fdocker.sock")
+	require.NoError(t, err)
+	require.Equal(t, "/var/run/docker.sock", path)
+
+	path, err = decoder.LookupPath(testSocketPath)
+	require.NoError(t, err)
+	require.Equal(t, testSocketPath, path)
+
+	path, err = decoder.LookupPath("%2")
+	require.Error(t, err)
+	require.Empty(t, path)
+
+	path, err = decoder.LookupPath("")
+	require.Error(t, err)
+	require.Empty(t, path)
+}
+
+func TestEncodeHostname(t *testing.T) {
+	require.Equal(t, "%2Fvar%2Frun%2Fdocker.sock", EncodeHostname("/var/run/docker.sock"))
+	require.Equal(t, "%40abstract%3A1", EncodeHostname("@abstract:1"))
+	require.Equal(t, "Az09-._~", EncodeHostname("Az09-._~"))
+
+	var decoder Decoder
+
+	for _, origin := range []string{"/var/run/docker.sock", "@abstract:1", "dir/sock et%"} {
+		path, err := decoder.LookupPath(EncodeHostname(origin))
+		require.NoError(t, err)
+		require.Equal(t, origin, path)
+
+		requestURL := url.URL{
+			Scheme: DefaultSchemeHTTP,
+			Host:   EncodeHostname(origin),
+		}
+
+		parsed, err := url.Parse(requestURL.String())
+		require.NoError(t, err)
+		require.Equal(t, requestURL.Host, parsed.Host)
+	}
+}
+
+func TestTransportDecoder(t *testing.T) {
+	const requestPath = "/request/path"
+
+	message := prepareMessage(t)
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	var router http.ServeMux
+
+	router.HandleFunc(
+		requestPath,
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(message)
+		},
+	)
+
+	server := &http.Server{
+		Handler:     &router,
+		ReadTimeout: time.Second,
+	}
+
+	serverErr := make(chan error)
+	defer close(serverErr)
+
+	var blank net.ListenConfig
+
+	listener, err := blank.Listen(t.Context(), unixNetworkName, socketPath)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}()
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	trt, err := New(Decoder{}, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   EncodeHostname(socketPath),
+		Path:   requestPath,
+	}
+
+	request, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodGet,
+		requestURL.String(),
+		http.NoBody,
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Do(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	output, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, message, output)
+	require.NoError(t, resp.Body.Close())
+
+	client.CloseIdleConnections()
+}
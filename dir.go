@@ -0,0 +1,224 @@
+package utr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// Default pattern of names of Unix domain sockets in a directory.
+	DefaultDirPattern = "*.sock"
+
+	dirPatternPlaceholder = "*"
+)
+
+// Provides adjusting of a directory resolver.
+type DirAdjuster func(rsv *DirResolver) error
+
+// Resolves paths to Unix domain sockets by hostnames using sockets placed in
+// a directory, e.g. hostname 'service' is resolved to '<dir>/service.sock'.
+//
+// Presence of Unix domain socket is checked on each lookup, if it is missing, then
+// [ErrPathNotFound] is returned.
+type DirResolver struct {
+	dir      string
+	notifier notifier
+	prefix   string
+	suffix   string
+}
+
+// Sets pattern of names of Unix domain sockets in the directory. Pattern must contain
+// exactly one '*', which is replaced by hostname, and must not contain path separators.
+//
+// If pattern is not set, then [DefaultDirPattern] will be used.
+func WithDirPattern(pattern string) DirAdjuster {
+	adj := func(rsv *DirResolver) error {
+		if strings.Count(pattern, dirPatternPlaceholder) != 1 {
+			return fmt.Errorf("%w: %s", ErrPatternInvalid, pattern)
+		}
+
+		if filepath.Base(pattern) != pattern {
+			return fmt.Errorf("%w: %s", ErrPatternInvalid, pattern)
+		}
+
+		rsv.prefix, rsv.suffix, _ = strings.Cut(pattern, dirPatternPlaceholder)
+
+		return nil
+	}
+
+	return adj
+}
+
+// Creates new directory resolver.
+func NewDirResolver(dir string, opts ...DirAdjuster) (*DirResolver, error) {
+	if dir == "" {
+		return nil, ErrDirEmpty
+	}
+
+	rsv := &DirResolver{
+		dir: dir,
+	}
+
+	if err := WithDirPattern(DefaultDirPattern)(rsv); err != nil {
+		return nil, err
+	}
+
+	for _, adj := range opts {
+		if err := adj(rsv); err != nil {
+			return nil, err
+		}
+	}
+
+	return rsv, nil
+}
+
+// Resolves path to Unix domain socket by hostname.
+func (rsv *DirResolver) LookupPath(hostname string) (string, error) {
+	name, err := rsv.name(hostname)
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(rsv.dir, name)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", ErrPathNotFound
+		}
+
+		return "", err
+	}
+
+	if info.Mode().Type() != fs.ModeSocket {
+		return "", fmt.Errorf("%w: %s", ErrPathNotSocket, path)
+	}
+
+	return path, nil
+}
+
+// Subscribes to removal of Unix domain sockets from the directory.
+//
+// Subscriber is called synchronously when removal is detected by
+// [DirResolver.Watch] method. Returns function that cancels the subscription.
+func (rsv *DirResolver) Subscribe(subscriber func(hostname, path string)) func() {
+	return rsv.notifier.subscribe(subscriber)
+}
+
+// Watches the directory and notifies subscribers when Unix domain sockets are removed
+// from it.
+//
+// On Linux the directory is watched using inotify, on other systems or if inotify
+// cannot be used, the directory is polled with the specified interval.
+//
+// Errors of reading of the directory are passed to the handler, if it is specified.
+//
+// Blocks until the context is done, then returns the context error. If watching is
+// interrupted, then returns the error.
+func (rsv *DirResolver) Watch(
+	ctx context.Context,
+	interval time.Duration,
+	handle func(err error),
+) error {
+	if interval <= 0 {
+		return ErrIntervalInvalid
+	}
+
+	rescan, err := rsv.rescanner(handle)
+	if err != nil {
+		return err
+	}
+
+	wch, err := newWatcher(rsv.dir)
+	if err != nil {
+		return poll(ctx, interval, rescan)
+	}
+
+	// Sockets could be removed between scanning and start of watching
+	rescan()
+
+	return wch.run(ctx, func(string, watchOp) { rescan() })
+}
+
+// Returns function that scans the directory and notifies subscribers about Unix domain
+// sockets removed since the previous scan.
+func (rsv *DirResolver) rescanner(handle func(err error)) (func(), error) {
+	sockets, err := rsv.scan()
+	if err != nil {
+		return nil, err
+	}
+
+	rescan := func() {
+		current, err := rsv.scan()
+		if err != nil {
+			if handle != nil {
+				handle(err)
+			}
+
+			return
+		}
+
+		for hostname, path := range sockets {
+			if _, exists := current[hostname]; !exists {
+				rsv.notifier.notify(hostname, path)
+			}
+		}
+
+		sockets = current
+	}
+
+	return rescan, nil
+}
+
+func (rsv *DirResolver) name(hostname string) (string, error) {
+	if err := isValidHostname(hostname); err != nil {
+		return "", err
+	}
+
+	name := rsv.prefix + hostname + rsv.suffix
+
+	if name == "." || name == ".." || filepath.Base(name) != name {
+		return "", fmt.Errorf("%w: %s", ErrHostnameInvalid, hostname)
+	}
+
+	return name, nil
+}
+
+func (rsv *DirResolver) hostname(name string) (string, bool) {
+	if len(name) <= len(rsv.prefix)+len(rsv.suffix) {
+		return "", false
+	}
+
+	if !strings.HasPrefix(name, rsv.prefix) || !strings.HasSuffix(name, rsv.suffix) {
+		return "", false
+	}
+
+	return name[len(rsv.prefix) : len(name)-len(rsv.suffix)], true
+}
+
+func (rsv *DirResolver) scan() (map[string]string, error) {
+	entries, err := os.ReadDir(rsv.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	sockets := make(map[string]string)
+
+	for _, entry := range entries {
+		if entry.Type() != fs.ModeSocket {
+			continue
+		}
+
+		if hostname, matched := rsv.hostname(entry.Name()); matched {
+			sockets[hostname] = filepath.Join(rsv.dir, entry.Name())
+		}
+	}
+
+	return sockets, nil
+}
@@ -0,0 +1,190 @@
+package utr
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithDirPattern(t *testing.T) {
+	rsv := &DirResolver{}
+
+	require.Error(t, WithDirPattern("")(rsv))
+	require.Error(t, WithDirPattern("sock")(rsv))
+	require.Error(t, WithDirPattern("*.*")(rsv))
+	require.Error(t, WithDirPattern("dir/*")(rsv))
+	require.Empty(t, rsv.prefix)
+	require.Empty(t, rsv.suffix)
+
+	require.NoError(t, WithDirPattern("sock-*.unix")(rsv))
+	require.Equal(t, "sock-", rsv.prefix)
+	require.Equal(t, ".unix", rsv.suffix)
+}
+
+func TestNewDirResolverBad(t *testing.T) {
+	resolver, err := NewDirResolver("")
+	require.Error(t, err)
+	require.Nil(t, resolver)
+
+	resolver, err = NewDirResolver(t.TempDir(), WithDirPattern(""))
+	require.Error(t, err)
+	require.Nil(t, resolver)
+}
+
+func TestDirResolver(t *testing.T) {
+	dir := t.TempDir()
+
+	listener, err := Listen(t.Context(), filepath.Join(dir, testSocketPath))
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, listener.Close())
+	}()
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "file.sock"), nil, 0o600))
+
+	resolver, err := NewDirResolver(dir)
+	require.NoError(t, err)
+
+	path, err := resolver.LookupPath(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, testSocketPath), path)
+
+	path, err = resolver.LookupPath("nonexistent")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, path)
+
+	path, err = resolver.LookupPath("file")
+	require.ErrorIs(t, err, ErrPathNotSocket)
+	require.Empty(t, path)
+
+	path, err = resolver.LookupPath("/" + testHostname)
+	require.ErrorIs(t, err, ErrHostnameInvalid)
+	require.Empty(t, path)
+
+	resolver, err = NewDirResolver(dir, WithDirPattern("*"))
+	require.NoError(t, err)
+
+	path, err = resolver.LookupPath(testSocketPath)
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, testSocketPath), path)
+
+	path, err = resolver.LookupPath("..")
+	require.ErrorIs(t, err, ErrHostnameInvalid)
+	require.Empty(t, path)
+}
+
+func TestDirResolverWatch(t *testing.T) {
+	testDirResolverWatchBase(t, false)
+	testDirResolverWatchBase(t, true)
+}
+
+func testDirResolverWatchBase(t *testing.T, usePolling bool) {
+	const interval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+
+	resolver, err := NewDirResolver(dir)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, resolver.Watch(t.Context(), 0, nil), ErrIntervalInvalid)
+
+	var (
+		mutex   sync.Mutex
+		removed []string
+	)
+
+	resolver.Subscribe(
+		func(hostname, path string) {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			require.Equal(t, filepath.Join(dir, hostname+".sock"), path)
+
+			removed = append(removed, hostname)
+		},
+	)
+
+	first, err := Listen(t.Context(), filepath.Join(dir, "first.sock"))
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	watchErr := make(chan error, 1)
+
+	go func() {
+		if usePolling {
+			rescan, err := resolver.rescanner(nil)
+			if err != nil {
+				watchErr <- err
+				return
+			}
+
+			watchErr <- poll(ctx, interval, rescan)
+
+			return
+		}
+
+		watchErr <- resolver.Watch(ctx, interval, nil)
+	}()
+
+	// Give the watcher time to scan the directory
+	time.Sleep(5 * interval)
+
+	second, err := Listen(t.Context(), filepath.Join(dir, "second.sock"))
+	require.NoError(t, err)
+
+	time.Sleep(5 * interval)
+
+	require.NoError(t, first.Close())
+	require.NoError(t, second.Close())
+
+	require.Eventually(
+		t,
+		func() bool {
+			mutex.Lock()
+			defer mutex.Unlock()
+
+			return len(removed) == 2
+		},
+		time.Second,
+		time.Millisecond,
+	)
+
+	require.ElementsMatch(t, []string{"first", "second"}, removed)
+
+	cancel()
+	require.ErrorIs(t, <-watchErr, context.Canceled)
+}
+
+func TestTransportDirResolver(t *testing.T) {
+	dir := t.TempDir()
+
+	listener, err := Listen(t.Context(), filepath.Join(dir, testSocketPath))
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, listener.Close())
+	}()
+
+	resolver, err := NewDirResolver(dir)
+	require.NoError(t, err)
+
+	trt, err := New(resolver, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	conn, err := trt.dial(t.Context(), unixNetworkName, net.JoinHostPort(testHostname, "80"))
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	conn, err = trt.dial(t.Context(), unixNetworkName, net.JoinHostPort("nonexistent", "80"))
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Nil(t, conn)
+}
@@ -0,0 +1,155 @@
+package utr
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// Default prefix of names of environment variables that contain path to Unix domain
+	// socket for a hostname.
+	DefaultEnvPrefix = "UTR_SOCKET_"
+	// Default name of environment variable that contains list of mappings of hostnames
+	// and paths to Unix domain sockets.
+	DefaultEnvList = "UTR_SOCKETS"
+
+	envListSeparator    = ","
+	envMappingSeparator = "="
+)
+
+// Provides adjusting of an environment resolver.
+type EnvAdjuster func(rsv *EnvResolver) error
+
+// Resolves paths to Unix domain sockets by hostnames using environment variables.
+//
+// Firstly, path is looked up in the environment variable whose name consists of the
+// prefix and the hostname converted to upper case with all characters except
+// letters and digits replaced by '_', e.g. path for hostname 'my-service' is taken
+// from UTR_SOCKET_MY_SERVICE. Note that different hostnames can be converted to the
+// same name of environment variable.
+//
+// Secondly, path is looked up in the environment variable that contains list of
+// mappings in the form of 'hostname=path' separated by ',', e.g.
+// UTR_SOCKETS=service=/run/service.sock,other=/run/other.sock.
+//
+// Environment variables are read on each lookup. Paths are validated in the same way
+// as in [Keeper].
+type EnvResolver struct {
+	list   string
+	prefix string
+}
+
+// Sets prefix of names of environment variables that contain path to Unix domain
+// socket for a hostname. Empty prefix disables lookup in such environment variables.
+//
+// If prefix is not set, then [DefaultEnvPrefix] will be used.
+func WithEnvPrefix(prefix string) EnvAdjuster {
+	adj := func(rsv *EnvResolver) error {
+		rsv.prefix = prefix
+		return nil
+	}
+
+	return adj
+}
+
+// Sets name of environment variable that contains list of mappings of hostnames and
+// paths to Unix domain sockets. Empty name disables lookup in the list.
+//
+// If name is not set, then [DefaultEnvList] will be used.
+func WithEnvList(name string) EnvAdjuster {
+	adj := func(rsv *EnvResolver) error {
+		rsv.list = name
+		return nil
+	}
+
+	return adj
+}
+
+// Creates new environment resolver.
+func NewEnvResolver(opts ...EnvAdjuster) (*EnvResolver, error) {
+	rsv := &EnvResolver{
+		list:   DefaultEnvList,
+		prefix: DefaultEnvPrefix,
+	}
+
+	for _, adj := range opts {
+		if err := adj(rsv); err != nil {
+			return nil, err
+		}
+	}
+
+	return rsv, nil
+}
+
+// Resolves path to Unix domain socket by hostname.
+func (rsv *EnvResolver) LookupPath(hostname string) (string, error) {
+	if rsv.prefix != "" {
+		name := rsv.prefix + envName(hostname)
+
+		if path := os.Getenv(name); path != "" {
+			if err := isValidPath(path); err != nil {
+				return "", fmt.Errorf("%s: %w", name, err)
+			}
+
+			return path, nil
+		}
+	}
+
+	if rsv.list != "" {
+		return rsv.lookupList(hostname)
+	}
+
+	return "", ErrPathNotFound
+}
+
+func (rsv *EnvResolver) lookupList(hostname string) (string, error) {
+	list := os.Getenv(rsv.list)
+	if list == "" {
+		return "", ErrPathNotFound
+	}
+
+	for mapping := range strings.SplitSeq(list, envListSeparator) {
+		mapped, path, found := strings.Cut(mapping, envMappingSeparator)
+		if !found {
+			return "", fmt.Errorf(
+				"%s: %w: separator '%s' is missing in %q",
+				rsv.list,
+				ErrSyntaxInvalid,
+				envMappingSeparator,
+				mapping,
+			)
+		}
+
+		if strings.TrimSpace(mapped) != hostname {
+			continue
+		}
+
+		path = strings.TrimSpace(path)
+
+		if err := isValidPath(path); err != nil {
+			return "", fmt.Errorf("%s: %w", rsv.list, err)
+		}
+
+		return path, nil
+	}
+
+	return "", ErrPathNotFound
+}
+
+func envName(hostname string) string {
+	converted := func(char rune) rune {
+		switch {
+		case 'a' <= char && char <= 'z':
+			return char - 'a' + 'A'
+		case 'A' <= char && char <= 'Z':
+			return char
+		case '0' <= char && char <= '9':
+			return char
+		}
+
+		return '_'
+	}
+
+	return strings.Map(converted, hostname)
+}
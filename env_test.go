@@ -0,0 +1,80 @@
+package utr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvName(t *testing.T) {
+	require.Equal(t, "SERVICE", envName(testHostname))
+	require.Equal(t, "MY_SERVICE_1_LOCAL", envName("my-service-1.local"))
+	require.Equal(t, "__", envName("ый"))
+}
+
+func TestEnvResolver(t *testing.T) {
+	t.Setenv(DefaultEnvPrefix+"MY_SERVICE", "/run/my-service.sock")
+	t.Setenv(DefaultEnvPrefix+"INVALID", strings.Repeat("a", maxPathSize))
+	t.Setenv(DefaultEnvList, "service=/run/service.sock, other = /run/other.sock,my-service=ignored")
+
+	resolver, err := NewEnvResolver()
+	require.NoError(t, err)
+
+	testEnvResolverPath(t, resolver, "my-service", "/run/my-service.sock")
+	testEnvResolverPath(t, resolver, testHostname, "/run/service.sock")
+	testEnvResolverPath(t, resolver, "other", "/run/other.sock")
+
+	path, err := resolver.LookupPath("invalid")
+	require.ErrorIs(t, err, ErrPathInvalid)
+	require.Empty(t, path)
+
+	path, err = resolver.LookupPath("nonexistent")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, path)
+
+	t.Setenv(DefaultEnvList, "service=/run/service.sock,broken,other=")
+
+	testEnvResolverPath(t, resolver, testHostname, "/run/service.sock")
+
+	path, err = resolver.LookupPath("other")
+	require.ErrorIs(t, err, ErrSyntaxInvalid)
+	require.Empty(t, path)
+
+	t.Setenv(DefaultEnvList, "other=")
+
+	path, err = resolver.LookupPath("other")
+	require.ErrorIs(t, err, ErrPathEmpty)
+	require.Empty(t, path)
+
+	t.Setenv(DefaultEnvList, "")
+
+	path, err = resolver.LookupPath(testHostname)
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, path)
+}
+
+func TestEnvResolverOpts(t *testing.T) {
+	t.Setenv(DefaultEnvPrefix+"SERVICE", "/run/default.sock")
+	t.Setenv("CUSTOM_SERVICE", "/run/custom.sock")
+	t.Setenv("CUSTOM_LIST", "other=/run/other.sock")
+
+	resolver, err := NewEnvResolver(WithEnvPrefix("CUSTOM_"), WithEnvList("CUSTOM_LIST"))
+	require.NoError(t, err)
+
+	testEnvResolverPath(t, resolver, testHostname, "/run/custom.sock")
+	testEnvResolverPath(t, resolver, "other", "/run/other.sock")
+
+	resolver, err = NewEnvResolver(WithEnvPrefix(""), WithEnvList(""))
+	require.NoError(t, err)
+
+	path, err := resolver.LookupPath(testHostname)
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, path)
+}
+
+func testEnvResolverPath(t *testing.T, resolver *EnvResolver, hostname, expected string) {
+	path, err := resolver.LookupPath(hostname)
+	require.NoError(t, err)
+	require.Equal(t, expected, path)
+}
@@ -3,12 +3,43 @@ package utr
 import "errors"
 
 var (
-	ErrHostnameAlreadyExists = errors.New("hostname is already exists")
-	ErrHostnameInvalid       = errors.New("hostname is invalid")
-	ErrPathNotFound          = errors.New("path not found")
-	ErrResolverEmpty         = errors.New("resolver is not specified")
-	ErrSchemeEmpty           = errors.New("scheme is not specified")
-	ErrSchemeInvalid         = errors.New("scheme is not valid")
-	ErrTransportEmpty        = errors.New("upstream transport is not specified")
-	ErrTransportInvalid      = errors.New("upstream transport is not a transport from net/http package")
+	ErrActivationInvalid      = errors.New("environment of socket activation is not valid")
+	ErrBackoffInvalid         = errors.New("backoff is not valid")
+	ErrBalancingInvalid       = errors.New("balancing policy is not valid")
+	ErrCredentialsUnsupported = errors.New("obtaining of peer credentials is not supported")
+	ErrDialMiddlewareEmpty    = errors.New("dial middleware is not specified")
+	ErrDialerEmpty            = errors.New("dialer is not specified")
+	ErrDirEmpty               = errors.New("directory is not specified")
+	ErrFileModeInvalid        = errors.New("file mode is not valid")
+	ErrHostnameAlreadyExists  = errors.New("hostname is already exists")
+	ErrHostnameEmpty          = errors.New("hostname is not specified")
+	ErrHostnameInvalid        = errors.New("hostname is invalid")
+	ErrIntervalInvalid        = errors.New("interval is not valid")
+	ErrLimitInvalid           = errors.New("limit is not valid")
+	ErrPathEjected            = errors.New("path is ejected as outlier")
+	ErrPathEmpty              = errors.New("path is not specified")
+	ErrPathInvalid            = errors.New("path is not valid")
+	ErrPathNotFound           = errors.New("path not found")
+	ErrPathNotSocket          = errors.New("path is not a socket")
+	ErrPatternInvalid         = errors.New("pattern is not valid")
+	ErrPeerMismatch           = errors.New("peer credentials do not match")
+	ErrPeerVerifierEmpty      = errors.New("peer verifier is not specified")
+	ErrProbeEmpty             = errors.New("health probe is not specified")
+	ErrProbeTransportEmpty    = errors.New("transport of health probe is not specified")
+	ErrResolverEmpty          = errors.New("resolver is not specified")
+	ErrResolverPanicked       = errors.New("resolver is panicked")
+	ErrSchemeEmpty            = errors.New("scheme is not specified")
+	ErrSchemeInvalid          = errors.New("scheme is not valid")
+	ErrSocketInUse            = errors.New("socket is already in use")
+	ErrStatusUnhealthy        = errors.New("response status is unhealthy")
+	ErrSyntaxInvalid          = errors.New("syntax is not valid")
+	ErrTLSConfigFuncEmpty     = errors.New("TLS config function is not specified")
+	ErrTTLInvalid             = errors.New("TTL is not valid")
+	ErrTargetInvalid          = errors.New("target is not valid")
+	ErrThresholdInvalid       = errors.New("threshold is not valid")
+	ErrTimeoutInvalid         = errors.New("timeout is not valid")
+	ErrTransportEmpty         = errors.New("upstream transport is not specified")
+	ErrTransportInvalid       = errors.New("upstream transport is not a transport from net/http package")
+	ErrWatchStopped           = errors.New("watching is stopped by the system")
+	ErrWatchUnsupported       = errors.New("watching is not supported")
 )
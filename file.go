@@ -0,0 +1,351 @@
+package utr
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+)
+
+// Error of parsing of a file with mappings of hostnames and paths to Unix domain
+// sockets. Err contains the reason of the parsing failure.
+type ParseError struct {
+	File string
+	Line int
+	Text string
+	Err  error
+}
+
+func (err *ParseError) Error() string {
+	return fmt.Sprintf("%s:%d: %q: %v", err.File, err.Line, err.Text, err.Err)
+}
+
+func (err *ParseError) Unwrap() error {
+	return err.Err
+}
+
+// Resolves paths to Unix domain sockets by hostnames using mappings loaded from a file.
+//
+// File contains JSON object with hostnames as keys and paths as values:
+//
+//	{
+//	    "service": "/run/service.sock",
+//	    "other": "/run/other dir/other.sock",
+//	    "abstract": "\u0000abstract"
+//	}
+//
+// Characters of paths are escaped according to JSON, e.g. to specify an address in
+// the abstract namespace starting with NUL character. Hostnames must not be repeated.
+//
+// Hostnames and paths are validated in the same way as in [Keeper].
+//
+// Mappings can be reloaded from the file using [FileResolver.Reload] and
+// [FileResolver.Watch] methods. Mappings are replaced atomically, so lookups see
+// either the previous mappings or the new ones.
+type FileResolver struct {
+	notifier notifier
+	path     string
+	table    atomic.Pointer[map[string]string]
+}
+
+// Creates new resolver and loads mappings of hostnames and paths to Unix domain sockets
+// from the file.
+//
+// Parsing errors are returned as [ParseError].
+func NewFileResolver(path string) (*FileResolver, error) {
+	table, err := loadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	rsv := &FileResolver{
+		path: path,
+	}
+
+	rsv.table.Store(&table)
+
+	return rsv, nil
+}
+
+// Resolves path to Unix domain socket by hostname.
+func (rsv *FileResolver) LookupPath(hostname string) (string, error) {
+	path, exists := (*rsv.table.Load())[hostname]
+	if !exists {
+		return "", ErrPathNotFound
+	}
+
+	return path, nil
+}
+
+// Subscribes to removal of mappings of hostnames and paths to Unix domain sockets.
+//
+// Subscriber is called synchronously after mapping is removed or its path is changed
+// by reloading. Returns function that cancels the subscription.
+func (rsv *FileResolver) Subscribe(subscriber func(hostname, path string)) func() {
+	return rsv.notifier.subscribe(subscriber)
+}
+
+// Reloads mappings of hostnames and paths to Unix domain sockets from the file.
+//
+// If the file cannot be loaded, then the previous mappings are kept.
+func (rsv *FileResolver) Reload() error {
+	table, err := loadFile(rsv.path)
+	if err != nil {
+		return err
+	}
+
+	previous := rsv.table.Swap(&table)
+
+	for hostname, path := range *previous {
+		if table[hostname] != path {
+			rsv.notifier.notify(hostname, path)
+		}
+	}
+
+	return nil
+}
+
+// Watches the file and reloads mappings of hostnames and paths to Unix domain sockets
+// when it is changed.
+//
+// On Linux the directory of the file is watched using inotify, on other systems or if
+// inotify cannot be used, the file is polled with the specified interval. Replacement
+// of the file by changing of a symlink located in the same directory, e.g. as it is
+// done for Kubernetes ConfigMap volumes, is also detected.
+//
+// Errors of reloading are passed to the handler, if it is specified, and the previous
+// mappings are kept.
+//
+// Blocks until the context is done, then returns the context error. If watching is
+// interrupted, then returns the error.
+func (rsv *FileResolver) Watch(
+	ctx context.Context,
+	interval time.Duration,
+	handle func(err error),
+) error {
+	if interval <= 0 {
+		return ErrIntervalInvalid
+	}
+
+	reload := func() {
+		if err := rsv.Reload(); err != nil && handle != nil {
+			handle(err)
+		}
+	}
+
+	wch, err := newWatcher(filepath.Dir(rsv.path))
+	if err != nil {
+		return rsv.poll(ctx, interval, reload)
+	}
+
+	previous, _ := os.Stat(rsv.path)
+
+	// File could be changed between loading and start of watching
+	reload()
+
+	base := filepath.Base(rsv.path)
+
+	handleEvent := func(name string, op watchOp) {
+		switch op {
+		case watchOpWrite, watchOpRemove:
+			current, _ := os.Stat(rsv.path)
+
+			// File can be replaced by changing of a symlink in the directory, e.g.
+			// '..data' symlink of Kubernetes ConfigMap volume, without events for
+			// the file itself
+			if name != base && isFileInfoEqual(previous, current) {
+				return
+			}
+
+			previous = current
+
+			reload()
+		case watchOpOverflow:
+			previous, _ = os.Stat(rsv.path)
+
+			reload()
+		case watchOpCreate:
+			// File is not written yet
+		}
+	}
+
+	return wch.run(ctx, handleEvent)
+}
+
+func (rsv *FileResolver) poll(ctx context.Context, interval time.Duration, reload func()) error {
+	previous, _ := os.Stat(rsv.path)
+
+	// File could be changed between loading and start of watching
+	reload()
+
+	check := func() {
+		current, _ := os.Stat(rsv.path)
+
+		if isFileInfoEqual(previous, current) {
+			return
+		}
+
+		previous = current
+
+		reload()
+	}
+
+	return poll(ctx, interval, check)
+}
+
+func isFileInfoEqual(first, second os.FileInfo) bool {
+	if first == nil || second == nil {
+		return first == nil && second == nil
+	}
+
+	return os.SameFile(first, second) &&
+		first.ModTime().Equal(second.ModTime()) &&
+		first.Size() == second.Size()
+}
+
+func loadFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	return parseFile(file, path)
+}
+
+func parseFile(reader io.Reader, name string) (map[string]string, error) {
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	table, offset, err := parseFileData(data)
+	if err != nil {
+		line, text := locateFileLine(data, offset)
+		return nil, &ParseError{File: name, Line: line, Text: text, Err: err}
+	}
+
+	return table, nil
+}
+
+// Returns offset in the data at which parsing is failed in case of error.
+func parseFileData(data []byte) (map[string]string, int64, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+
+	if err := expectFileDelim(decoder, '{'); err != nil {
+		return nil, fileErrorOffset(decoder, err), err
+	}
+
+	table := make(map[string]string)
+
+	for decoder.More() {
+		hostname, err := readFileString(decoder)
+		if err == nil {
+			err = isValidFileHostname(table, hostname)
+		}
+
+		if err != nil {
+			return nil, fileErrorOffset(decoder, err), err
+		}
+
+		path, err := readFileString(decoder)
+		if err == nil {
+			err = isValidPath(path)
+		}
+
+		if err != nil {
+			return nil, fileErrorOffset(decoder, err), err
+		}
+
+		table[hostname] = path
+	}
+
+	if err := expectFileDelim(decoder, '}'); err != nil {
+		return nil, fileErrorOffset(decoder, err), err
+	}
+
+	if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+		err := fmt.Errorf("%w: unexpected data after object", ErrSyntaxInvalid)
+		return nil, decoder.InputOffset(), err
+	}
+
+	return table, 0, nil
+}
+
+func expectFileDelim(decoder *json.Decoder, expected json.Delim) error {
+	token, err := decoder.Token()
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrSyntaxInvalid, err)
+	}
+
+	if token != expected {
+		return fmt.Errorf("%w: '%s' is expected", ErrSyntaxInvalid, expected)
+	}
+
+	return nil
+}
+
+func readFileString(decoder *json.Decoder) (string, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrSyntaxInvalid, err)
+	}
+
+	text, casted := token.(string)
+	if !casted {
+		return "", fmt.Errorf("%w: string is expected instead of %v", ErrSyntaxInvalid, token)
+	}
+
+	return text, nil
+}
+
+func isValidFileHostname(table map[string]string, hostname string) error {
+	if hostname == "" {
+		return ErrHostnameEmpty
+	}
+
+	if err := isValidHostname(hostname); err != nil {
+		return err
+	}
+
+	if _, exists := table[hostname]; exists {
+		return ErrHostnameAlreadyExists
+	}
+
+	return nil
+}
+
+// Syntax errors contain their own offset, for other errors offset of the decoder
+// points right after the token that caused the error.
+func fileErrorOffset(decoder *json.Decoder, err error) int64 {
+	var syntaxErr *json.SyntaxError
+
+	if errors.As(err, &syntaxErr) {
+		return syntaxErr.Offset
+	}
+
+	return decoder.InputOffset()
+}
+
+// Returns number and text of the line with the byte preceding the offset.
+func locateFileLine(data []byte, offset int64) (int, string) {
+	position := min(max(int(offset)-1, 0), len(data))
+
+	start := bytes.LastIndexByte(data[:position], '\n') + 1
+
+	end := bytes.IndexByte(data[position:], '\n')
+	if end < 0 {
+		end = len(data)
+	} else {
+		end += position
+	}
+
+	return bytes.Count(data[:position], []byte{'\n'}) + 1, string(data[start:end])
+}
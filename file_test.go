@@ -0,0 +1,317 @@
+package utr
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileResolver(t *testing.T) {
+	const content = `{
+	"service": "/run/service.sock",
+	"other":   "/run/other dir/other.sock",
+
+	"abstract": "\u0000abstract"
+}
+`
+
+	path := filepath.Join(t.TempDir(), "sockets.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	resolver, err := NewFileResolver(path)
+	require.NoError(t, err)
+
+	expected := map[string]string{
+		"service":  "/run/service.sock",
+		"other":    "/run/other dir/other.sock",
+		"abstract": "\x00abstract",
+	}
+
+	for hostname, expectedPath := range expected {
+		path, err := resolver.LookupPath(hostname)
+		require.NoError(t, err)
+		require.Equal(t, expectedPath, path)
+	}
+
+	path, err = resolver.LookupPath("nonexistent")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, path)
+
+	empty := filepath.Join(t.TempDir(), "sockets.json")
+
+	require.NoError(t, os.WriteFile(empty, []byte("{}"), 0o600))
+
+	resolver, err = NewFileResolver(empty)
+	require.NoError(t, err)
+
+	path, err = resolver.LookupPath("service")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, path)
+}
+
+func TestFileResolverNotExists(t *testing.T) {
+	resolver, err := NewFileResolver(filepath.Join(t.TempDir(), "sockets.json"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+	require.Nil(t, resolver)
+}
+
+func TestParseFile(t *testing.T) {
+	testParseFileError(t, "", 1, ErrSyntaxInvalid)
+	testParseFileError(t, `["service"]`, 1, ErrSyntaxInvalid)
+	testParseFileError(t, "{\n\"service\" \"/run/service.sock\"\n}", 2, ErrSyntaxInvalid)
+	testParseFileError(t, "{\n\"service\": \"/run/service.sock\"", 2, ErrSyntaxInvalid)
+	testParseFileError(t, "{\n\"service\": 1\n}", 2, ErrSyntaxInvalid)
+	testParseFileError(t, "{\n\"service\": {}\n}", 2, ErrSyntaxInvalid)
+	testParseFileError(t, "{}\n{}", 2, ErrSyntaxInvalid)
+	testParseFileError(t, "{\n\n\"\": \"/run/service.sock\"\n}", 3, ErrHostnameEmpty)
+	testParseFileError(t, "{\n\"/service\": \"/run/service.sock\"\n}", 2, ErrHostnameInvalid)
+	testParseFileError(t, "{\n\"service\": \"\"\n}", 2, ErrPathEmpty)
+	testParseFileError(t, "{\n\"service\": \"dir\\u0000/service.sock\"\n}", 2, ErrPathInvalid)
+	testParseFileError(
+		t,
+		"{\n\"service\": \"/run/service.sock\",\n\"service\": \"/run/other.sock\"\n}",
+		3,
+		ErrHostnameAlreadyExists,
+	)
+}
+
+func testParseFileError(t *testing.T, content string, line int, expected error) {
+	table, err := parseFile(strings.NewReader(content), "sockets.json")
+	require.ErrorIs(t, err, expected)
+	require.Nil(t, table)
+
+	var parseErr *ParseError
+
+	require.ErrorAs(t, err, &parseErr)
+	require.Equal(t, "sockets.json", parseErr.File)
+	require.Equal(t, line, parseErr.Line)
+	require.Equal(t, strings.Split(content, "\n")[line-1], parseErr.Text)
+	require.Contains(t, parseErr.Error(), "sockets.json:")
+}
+
+func TestFileResolverReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sockets.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"first": "first.sock", "second": "second.sock"}`), 0o600))
+
+	resolver, err := NewFileResolver(path)
+	require.NoError(t, err)
+
+	removed := make(map[string]string)
+
+	resolver.Subscribe(
+		func(hostname, path string) {
+			removed[hostname] = path
+		},
+	)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"first": "other.sock", "third": "third.sock"}`), 0o600))
+	require.NoError(t, resolver.Reload())
+
+	require.Equal(t, map[string]string{"first": "first.sock", "second": "second.sock"}, removed)
+
+	socketPath, err := resolver.LookupPath("first")
+	require.NoError(t, err)
+	require.Equal(t, "other.sock", socketPath)
+
+	socketPath, err = resolver.LookupPath("second")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, socketPath)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"first"}`), 0o600))
+	require.ErrorIs(t, resolver.Reload(), ErrSyntaxInvalid)
+
+	socketPath, err = resolver.LookupPath("third")
+	require.NoError(t, err)
+	require.Equal(t, "third.sock", socketPath)
+}
+
+func TestFileResolverWatch(t *testing.T) {
+	testFileResolverWatchBase(t, false)
+	testFileResolverWatchBase(t, true)
+}
+
+func testFileResolverWatchBase(t *testing.T, usePolling bool) {
+	const interval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sockets.json")
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"first": "first.sock"}`), 0o600))
+
+	resolver, err := NewFileResolver(path)
+	require.NoError(t, err)
+
+	require.ErrorIs(t, resolver.Watch(t.Context(), 0, nil), ErrIntervalInvalid)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	reloadErrs := make(chan error, 10)
+	watchErr := make(chan error, 1)
+
+	handle := func(err error) {
+		select {
+		case reloadErrs <- err:
+		default:
+		}
+	}
+
+	go func() {
+		if usePolling {
+			reload := func() {
+				if err := resolver.Reload(); err != nil {
+					handle(err)
+				}
+			}
+
+			watchErr <- resolver.poll(ctx, interval, reload)
+
+			return
+		}
+
+		watchErr <- resolver.Watch(ctx, interval, handle)
+	}()
+
+	// Atomic replacement of the file like editors do
+	temp := filepath.Join(dir, "sockets.json.tmp")
+
+	require.NoError(t, os.WriteFile(temp, []byte(`{"second": "second.sock"}`), 0o600))
+	require.NoError(t, os.Rename(temp, path))
+
+	require.Eventually(
+		t,
+		func() bool {
+			socketPath, err := resolver.LookupPath("second")
+			return err == nil && socketPath == "second.sock"
+		},
+		time.Second,
+		time.Millisecond,
+	)
+
+	socketPath, err := resolver.LookupPath("first")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, socketPath)
+
+	// Overwriting of the file in place
+	require.NoError(t, os.WriteFile(path, []byte(`{"third": "third.sock"}`), 0o600))
+
+	require.Eventually(
+		t,
+		func() bool {
+			socketPath, err := resolver.LookupPath("third")
+			return err == nil && socketPath == "third.sock"
+		},
+		time.Second,
+		time.Millisecond,
+	)
+
+	require.NoError(t, os.Remove(path))
+
+	require.Eventually(
+		t,
+		func() bool {
+			select {
+			case err := <-reloadErrs:
+				return errors.Is(err, os.ErrNotExist)
+			default:
+				return false
+			}
+		},
+		time.Second,
+		time.Millisecond,
+	)
+
+	socketPath, err = resolver.LookupPath("third")
+	require.NoError(t, err)
+	require.Equal(t, "third.sock", socketPath)
+
+	cancel()
+	require.ErrorIs(t, <-watchErr, context.Canceled)
+}
+
+func TestFileResolverWatchSymlink(t *testing.T) {
+	testFileResolverWatchSymlink(t, false)
+	testFileResolverWatchSymlink(t, true)
+}
+
+// Layout of Kubernetes ConfigMap volume, where the file is replaced by swapping of
+// the '..data' symlink.
+func testFileResolverWatchSymlink(t *testing.T, usePolling bool) {
+	const interval = 10 * time.Millisecond
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "sockets.json")
+
+	writeVersion := func(version string, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o700))
+
+		versionPath := filepath.Join(dir, version, "sockets.json")
+
+		require.NoError(t, os.WriteFile(versionPath, []byte(content), 0o600))
+	}
+
+	swapData := func(version string) {
+		temp := filepath.Join(dir, "..data_tmp")
+
+		require.NoError(t, os.Symlink(version, temp))
+		require.NoError(t, os.Rename(temp, filepath.Join(dir, "..data")))
+	}
+
+	writeVersion("..first", `{"first": "first.sock"}`)
+	swapData("..first")
+
+	require.NoError(t, os.Symlink(filepath.Join("..data", "sockets.json"), path))
+
+	resolver, err := NewFileResolver(path)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	watchErr := make(chan error, 1)
+
+	go func() {
+		if usePolling {
+			reload := func() { _ = resolver.Reload() }
+
+			watchErr <- resolver.poll(ctx, interval, reload)
+
+			return
+		}
+
+		watchErr <- resolver.Watch(ctx, interval, nil)
+	}()
+
+	// The first replacement can be loaded at start of watching, so watching is
+	// surely started on the second one. Content is of the same size to not rely
+	// on the size for detection of changes
+	for _, hostname := range []string{"other", "third"} {
+		writeVersion(".."+hostname, `{"`+hostname+`": "`+hostname+`.sock"}`)
+		swapData(".." + hostname)
+
+		require.Eventually(
+			t,
+			func() bool {
+				socketPath, err := resolver.LookupPath(hostname)
+				return err == nil && socketPath == hostname+".sock"
+			},
+			time.Second,
+			time.Millisecond,
+		)
+	}
+
+	socketPath, err := resolver.LookupPath("other")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, socketPath)
+
+	cancel()
+	require.ErrorIs(t, <-watchErr, context.Canceled)
+}
@@ -0,0 +1,371 @@
+package utr
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Default timeout of a single health probe.
+const DefaultHealthTimeout = time.Second
+
+// Provides adjusting of a health checker.
+type HealthAdjuster func(hch *HealthChecker) error
+
+// Checks health of Unix domain sockets mapped in the [Keeper] and resolves paths to
+// Unix domain sockets by hostnames skipping unhealthy ones.
+//
+// By default, Unix domain socket is considered healthy if it can be dialed. Paths are
+// considered healthy until they are checked. If all paths of a hostname are unhealthy,
+// then all of them are returned, so dialing is still attempted.
+//
+// Health checker can be passed to [New] as the [Resolver], then the [Transport]
+// dials only healthy paths.
+type HealthChecker struct {
+	keeper  *Keeper
+	probe   func(ctx context.Context, hostname, path string) error
+	timeout time.Duration
+
+	unhealthy sync.Map
+}
+
+type healthKey struct {
+	hostname string
+	path     string
+}
+
+type healthPathKey struct{}
+
+// Sets timeout of a single health probe.
+//
+// If timeout is not set, then [DefaultHealthTimeout] will be used.
+func WithHealthTimeout(timeout time.Duration) HealthAdjuster {
+	adj := func(hch *HealthChecker) error {
+		if timeout <= 0 {
+			return ErrTimeoutInvalid
+		}
+
+		hch.timeout = timeout
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets function that probes health of Unix domain socket, socket is considered
+// healthy if function returns no error.
+//
+// Function is called with context limited by timeout of a health probe.
+func WithHealthProbe(probe func(ctx context.Context, hostname, path string) error) HealthAdjuster {
+	adj := func(hch *HealthChecker) error {
+		if probe == nil {
+			return ErrProbeEmpty
+		}
+
+		hch.probe = probe
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets HTTP probe of health of Unix domain socket. Probe sends GET request with
+// the target, e.g. '/healthz', and hostname as host over a new connection to Unix domain
+// socket, socket is considered healthy if response status code is 2xx.
+//
+// Connection is established with the dialer, dial middlewares and peer verifiers of
+// the transport. Resolver of the transport is not used, so the transport can be created
+// over the same [Keeper] as the health checker.
+func WithHealthHTTPProbe(trt *Transport, target string) HealthAdjuster {
+	return withHTTPProbe(trt, target, httpScheme)
+}
+
+// Like the [WithHealthHTTPProbe], but request is sent over TLS connection established
+// with the current TLS configuration of the transport.
+func WithHealthHTTPSProbe(trt *Transport, target string) HealthAdjuster {
+	return withHTTPProbe(trt, target, httpsScheme)
+}
+
+func withHTTPProbe(trt *Transport, target, scheme string) HealthAdjuster {
+	adj := func(hch *HealthChecker) error {
+		if trt == nil {
+			return ErrProbeTransportEmpty
+		}
+
+		parsed, err := url.Parse(target)
+		if err != nil {
+			return fmt.Errorf("%w: %w", ErrTargetInvalid, err)
+		}
+
+		if parsed.Scheme != "" || parsed.Host != "" || !strings.HasPrefix(parsed.Path, "/") {
+			return fmt.Errorf("%w: %s", ErrTargetInvalid, target)
+		}
+
+		parsed.Scheme = scheme
+
+		hch.probe = newHTTPProbe(trt, parsed)
+
+		return nil
+	}
+
+	return adj
+}
+
+// Creates new health checker of Unix domain sockets mapped in the keeper.
+func NewHealthChecker(keeper *Keeper, opts ...HealthAdjuster) (*HealthChecker, error) {
+	if keeper == nil {
+		return nil, ErrResolverEmpty
+	}
+
+	hch := &HealthChecker{
+		keeper:  keeper,
+		probe:   dialProbe,
+		timeout: DefaultHealthTimeout,
+	}
+
+	for _, adj := range opts {
+		if err := adj(hch); err != nil {
+			return nil, err
+		}
+	}
+
+	return hch, nil
+}
+
+// Periodically checks health of all Unix domain sockets mapped in the keeper with
+// the specified interval, the first check is performed immediately.
+//
+// Changes of health state are passed to the handler, if it is specified. Handler is
+// called synchronously, after all Unix domain sockets are checked.
+//
+// Blocks until the context is done, then returns the context error.
+func (hch *HealthChecker) Run(
+	ctx context.Context,
+	interval time.Duration,
+	handle func(hostname, path string, healthy bool),
+) error {
+	if interval <= 0 {
+		return ErrIntervalInvalid
+	}
+
+	hch.check(ctx, handle)
+
+	return poll(ctx, interval, func() { hch.check(ctx, handle) })
+}
+
+// Returns whether Unix domain socket is considered healthy for hostname.
+func (hch *HealthChecker) IsHealthy(hostname, path string) bool {
+	_, unhealthy := hch.unhealthy.Load(healthKey{hostname: hostname, path: path})
+	return !unhealthy
+}
+
+func (hch *HealthChecker) check(
+	ctx context.Context,
+	handle func(hostname, path string, healthy bool),
+) {
+	snapshot := hch.keeper.SnapshotPaths()
+
+	keys := make([]healthKey, 0, len(snapshot))
+
+	for hostname, paths := range snapshot {
+		for _, path := range paths {
+			keys = append(keys, healthKey{hostname: hostname, path: path})
+		}
+	}
+
+	errs := make([]error, len(keys))
+
+	var wg sync.WaitGroup
+
+	for id, key := range keys {
+		wg.Go(func() { errs[id] = hch.probeKey(ctx, key) })
+	}
+
+	wg.Wait()
+
+	// Probes interrupted by the context do not characterize health of sockets
+	if ctx.Err() != nil {
+		return
+	}
+
+	for id, key := range keys {
+		changed := hch.mark(key, errs[id] == nil)
+
+		if changed && handle != nil {
+			handle(key.hostname, key.path, errs[id] == nil)
+		}
+	}
+
+	hch.unhealthy.Range(
+		func(key, _ any) bool {
+			//nolint:revive,forcetypeassert // Key type is fully controlled
+			typed := key.(healthKey)
+
+			if !slices.Contains(snapshot[typed.hostname], typed.path) {
+				hch.unhealthy.Delete(key)
+			}
+
+			return true
+		},
+	)
+}
+
+func (hch *HealthChecker) probeKey(ctx context.Context, key healthKey) error {
+	ctx, cancel := context.WithTimeout(ctx, hch.timeout)
+	defer cancel()
+
+	return hch.probe(ctx, key.hostname, key.path)
+}
+
+// Returns whether health state is changed.
+func (hch *HealthChecker) mark(key healthKey, healthy bool) bool {
+	if healthy {
+		_, changed := hch.unhealthy.LoadAndDelete(key)
+		return changed
+	}
+
+	_, loaded := hch.unhealthy.LoadOrStore(key, struct{}{})
+
+	return !loaded
+}
+
+// Resolves path to Unix domain socket by hostname skipping unhealthy paths.
+func (hch *HealthChecker) LookupPath(hostname string) (string, error) {
+	return hch.LookupPathContext(context.Background(), hostname)
+}
+
+// Resolves path to Unix domain socket by hostname with respect to the context
+// skipping unhealthy paths.
+func (hch *HealthChecker) LookupPathContext(ctx context.Context, hostname string) (string, error) {
+	paths, err := hch.LookupPathsContext(ctx, hostname)
+	if err != nil {
+		return "", err
+	}
+
+	return paths[0], nil
+}
+
+// Resolves all healthy paths to Unix domain sockets by hostname.
+func (hch *HealthChecker) LookupPaths(hostname string) ([]string, error) {
+	return hch.LookupPathsContext(context.Background(), hostname)
+}
+
+// Resolves all healthy paths to Unix domain sockets by hostname with respect to
+// the context.
+func (hch *HealthChecker) LookupPathsContext(ctx context.Context, hostname string) ([]string, error) {
+	paths, err := hch.keeper.LookupPathsContext(ctx, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	healthy := slices.DeleteFunc(
+		slices.Clone(paths),
+		func(path string) bool { return !hch.IsHealthy(hostname, path) },
+	)
+
+	if len(healthy) == 0 {
+		return paths, nil
+	}
+
+	return healthy, nil
+}
+
+// Subscribes to removal of mappings of hostnames and paths to Unix domain sockets in
+// the keeper. Returns function that cancels the subscription.
+func (hch *HealthChecker) Subscribe(subscriber func(hostname, path string)) func() {
+	return hch.keeper.Subscribe(subscriber)
+}
+
+func dialProbe(ctx context.Context, _, path string) error {
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, unixNetworkName, path)
+	if err != nil {
+		return err
+	}
+
+	return conn.Close()
+}
+
+// Connections are not pooled, so each probe dials the path passed in the context
+// rather than one chosen by the resolver.
+func newHTTPProbe(trt *Transport, target *url.URL) func(ctx context.Context, hostname, path string) error {
+	dial := func(ctx context.Context, _, addr string) (net.Conn, error) {
+		hostname, _, _ := net.SplitHostPort(addr)
+
+		//nolint:revive,forcetypeassert // Value type is fully controlled
+		return trt.dialFunc(ctx, hostname, ctx.Value(healthPathKey{}).(string))
+	}
+
+	dialTLS := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		hostname, _, _ := net.SplitHostPort(addr)
+
+		conn, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+
+		config, err := trt.tlsConfig(trt.tlsState.Load().config, hostname)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		tlsConn := tls.Client(conn, config)
+
+		if err := trt.handshake(ctx, tlsConn); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+
+		return tlsConn, nil
+	}
+
+	httpTransport := trt.base.Clone()
+
+	httpTransport.DialContext = dial
+	httpTransport.DialTLSContext = dialTLS
+	httpTransport.DisableKeepAlives = true
+	httpTransport.Proxy = nil
+
+	probe := func(ctx context.Context, hostname, path string) error {
+		requestURL := *target
+		requestURL.Host = hostname
+
+		request, err := http.NewRequestWithContext(
+			context.WithValue(ctx, healthPathKey{}, path),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		if err != nil {
+			return err
+		}
+
+		resp, err := httpTransport.RoundTrip(request)
+		if err != nil {
+			return err
+		}
+
+		if err := resp.Body.Close(); err != nil {
+			return err
+		}
+
+		if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+			return fmt.Errorf("%w: %d", ErrStatusUnhealthy, resp.StatusCode)
+		}
+
+		return nil
+	}
+
+	return probe
+}
@@ -0,0 +1,272 @@
+package utr
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type healthChange struct {
+	hostname string
+	path     string
+	healthy  bool
+}
+
+func TestNewHealthCheckerBad(t *testing.T) {
+	hch, err := NewHealthChecker(nil)
+	require.Error(t, err)
+	require.Nil(t, hch)
+
+	for _, adj := range []HealthAdjuster{
+		WithHealthTimeout(0),
+		WithHealthProbe(nil),
+		WithHealthHTTPProbe(nil, "/healthz"),
+		WithHealthHTTPProbe(&Transport{}, ""),
+		WithHealthHTTPProbe(&Transport{}, "healthz"),
+		WithHealthHTTPProbe(&Transport{}, "http://service/healthz"),
+		WithHealthHTTPSProbe(&Transport{}, "/%zz"),
+	} {
+		hch, err := NewHealthChecker(&Keeper{}, adj)
+		require.Error(t, err)
+		require.Nil(t, hch)
+	}
+
+	hch, err = NewHealthChecker(&Keeper{})
+	require.NoError(t, err)
+	require.ErrorIs(t, hch.Run(t.Context(), 0, nil), ErrIntervalInvalid)
+}
+
+func TestHealthChecker(t *testing.T) {
+	var (
+		alive   = filepath.Join(t.TempDir(), testSocketPath)
+		revived = filepath.Join(t.TempDir(), testSocketPath)
+	)
+
+	listener, err := Listen(t.Context(), alive)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, listener.Close())
+	}()
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPaths(testHostname, revived, alive))
+
+	hch, err := NewHealthChecker(&keeper, WithHealthTimeout(time.Second))
+	require.NoError(t, err)
+
+	var changes []healthChange
+
+	handle := func(hostname, path string, healthy bool) {
+		changes = append(changes, healthChange{hostname, path, healthy})
+	}
+
+	testHealthCheckerPaths(t, hch, revived, alive)
+
+	hch.check(t.Context(), handle)
+	require.Equal(t, []healthChange{{testHostname, revived, false}}, changes)
+	require.False(t, hch.IsHealthy(testHostname, revived))
+	require.True(t, hch.IsHealthy(testHostname, alive))
+	testHealthCheckerPaths(t, hch, alive)
+
+	hch.check(t.Context(), handle)
+	require.Len(t, changes, 1)
+
+	relistener, err := Listen(t.Context(), revived)
+	require.NoError(t, err)
+
+	hch.check(t.Context(), handle)
+	require.Equal(
+		t,
+		[]healthChange{
+			{testHostname, revived, false},
+			{testHostname, revived, true},
+		},
+		changes,
+	)
+	testHealthCheckerPaths(t, hch, revived, alive)
+
+	require.NoError(t, relistener.Close())
+	require.NoError(t, keeper.ReplacePaths(testHostname, revived))
+
+	hch.check(t.Context(), handle)
+	require.Len(t, changes, 3)
+
+	// All paths are unhealthy
+	testHealthCheckerPaths(t, hch, revived)
+
+	require.NoError(t, keeper.ReplacePaths(testHostname, alive))
+
+	hch.check(t.Context(), handle)
+	require.Len(t, changes, 3)
+	require.True(t, hch.IsHealthy(testHostname, revived))
+
+	path, err := hch.LookupPath("nonexistent")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Empty(t, path)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	hch.check(ctx, handle)
+	require.Len(t, changes, 3)
+	require.ErrorIs(t, hch.Run(ctx, time.Millisecond, handle), context.Canceled)
+}
+
+func TestHealthCheckerHTTP(t *testing.T) {
+	testHealthCheckerHTTP(t, false)
+}
+
+func TestHealthCheckerHTTPS(t *testing.T) {
+	testHealthCheckerHTTP(t, true)
+}
+
+func testHealthCheckerHTTP(t *testing.T, useTLS bool) {
+	var (
+		healthy   = filepath.Join(t.TempDir(), testSocketPath)
+		unhealthy = filepath.Join(t.TempDir(), testSocketPath)
+	)
+
+	caPool, serverCerts, _ := genTempPKI(t, testHostname)
+
+	var (
+		servers = make([]*http.Server, 0, 2)
+		errs    = make([]chan error, 0, 2)
+	)
+
+	defer func() {
+		for id, server := range servers {
+			require.NoError(t, server.Shutdown(t.Context()))
+			require.Equal(t, http.ErrServerClosed, <-errs[id])
+		}
+	}()
+
+	for _, socketPath := range []string{healthy, unhealthy} {
+		status := http.StatusOK
+
+		if socketPath == unhealthy {
+			status = http.StatusServiceUnavailable
+		}
+
+		listener, err := Listen(t.Context(), socketPath)
+		require.NoError(t, err)
+
+		if useTLS {
+			listenTLSConfig := &tls.Config{
+				Certificates: serverCerts,
+				MinVersion:   tls.VersionTLS13,
+			}
+
+			listener = tls.NewListener(listener, listenTLSConfig)
+		}
+
+		server := &http.Server{
+			Handler: http.HandlerFunc(
+				func(w http.ResponseWriter, r *http.Request) {
+					if r.URL.Path != "/healthz" || r.Host != testHostname || (r.TLS != nil) != useTLS {
+						w.WriteHeader(http.StatusNotFound)
+						return
+					}
+
+					w.WriteHeader(status)
+				},
+			),
+			ReadTimeout: time.Second,
+		}
+
+		serverErr := make(chan error, 1)
+
+		go func() {
+			serverErr <- server.Serve(listener)
+		}()
+
+		servers = append(servers, server)
+		errs = append(errs, serverErr)
+	}
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPaths(testHostname, unhealthy, healthy))
+
+	httpTransport := cloneDefaultHTTPTransport(t)
+
+	httpTransport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		RootCAs:    caPool,
+	}
+
+	withHTTPProbe := WithHealthHTTPProbe
+	if useTLS {
+		withHTTPProbe = WithHealthHTTPSProbe
+	}
+
+	probeTransport, err := New(&keeper, httpTransport)
+	require.NoError(t, err)
+
+	hch, err := NewHealthChecker(&keeper, withHTTPProbe(probeTransport, "/healthz"))
+	require.NoError(t, err)
+
+	changes := make(chan healthChange, 1)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+
+	runErr := make(chan error, 1)
+
+	go func() {
+		runErr <- hch.Run(
+			ctx,
+			time.Millisecond,
+			func(hostname, path string, healthy bool) {
+				changes <- healthChange{hostname, path, healthy}
+			},
+		)
+	}()
+
+	require.Equal(t, healthChange{testHostname, unhealthy, false}, <-changes)
+	testHealthCheckerPaths(t, hch, healthy)
+
+	trt, err := New(hch, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	conn, err := trt.dial(t.Context(), "", testHostname+":80")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+
+	cancel()
+	require.ErrorIs(t, <-runErr, context.Canceled)
+
+	// Peer verifiers of the transport are applied to connections of the probe
+	errPeer := errors.New("peer is rejected")
+
+	probeTransport, err = New(
+		&keeper,
+		httpTransport,
+		WithPeerVerifier(func(string, Credentials) error { return errPeer }),
+	)
+	require.NoError(t, err)
+
+	hch, err = NewHealthChecker(&keeper, withHTTPProbe(probeTransport, "/healthz"))
+	require.NoError(t, err)
+
+	hch.check(t.Context(), nil)
+	require.False(t, hch.IsHealthy(testHostname, healthy))
+	require.False(t, hch.IsHealthy(testHostname, unhealthy))
+}
+
+func testHealthCheckerPaths(t *testing.T, hch *HealthChecker, expected ...string) {
+	paths, err := hch.LookupPaths(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, expected, paths)
+
+	path, err := hch.LookupPathContext(t.Context(), testHostname)
+	require.NoError(t, err)
+	require.Equal(t, expected[0], path)
+}
@@ -1,26 +1,265 @@
 package utr
 
 import (
+	"context"
 	"fmt"
 	"net/url"
+	"slices"
 	"sync"
 )
 
 // Keeps and resolves mappings of hostnames and paths to Unix domain sockets.
+//
+// Hostname can be mapped to several paths, in this case the [Transport] spreads new
+// connections across them.
+//
+// On Linux, path can be an address in the abstract namespace, which starts with '@' or
+// NUL character.
+//
+// Lookups are performed without locking, while modifications are serialized with each
+// other and with taking of snapshots.
 type Keeper struct {
-	table sync.Map
+	length   int
+	mutex    sync.RWMutex
+	notifier notifier
+	table    sync.Map
 }
 
 // Adds mapping of hostname and path to Unix domain socket.
 func (kpr *Keeper) AddPath(hostname, path string) error {
-	if err := isValidHostname(hostname); err != nil {
+	return kpr.AddPaths(hostname, path)
+}
+
+// Adds mapping of hostname and several paths to Unix domain sockets.
+func (kpr *Keeper) AddPaths(hostname string, paths ...string) error {
+	if err := isValidMapping(hostname, paths); err != nil {
 		return err
 	}
 
-	if prev, exists := kpr.table.LoadOrStore(hostname, path); exists {
-		if prev != path {
+	kpr.mutex.Lock()
+	defer kpr.mutex.Unlock()
+
+	if prev, exists := kpr.table.LoadOrStore(hostname, slices.Clone(paths)); exists {
+		//nolint:revive,forcetypeassert // Value type is fully controlled
+		if !slices.Equal(prev.([]string), paths) {
 			return ErrHostnameAlreadyExists
 		}
+
+		return nil
+	}
+
+	kpr.length++
+
+	return nil
+}
+
+// Adds mapping of hostname and path to Unix domain socket or replaces the existing one.
+//
+// If the existing path differs from the new one, then subscribers are notified about
+// removal of the existing path.
+func (kpr *Keeper) ReplacePath(hostname, path string) error {
+	return kpr.ReplacePaths(hostname, path)
+}
+
+// Adds mapping of hostname and several paths to Unix domain sockets or replaces
+// the existing one.
+//
+// Subscribers are notified about removal of each existing path that is not present
+// among the new ones.
+func (kpr *Keeper) ReplacePaths(hostname string, paths ...string) error {
+	if err := isValidMapping(hostname, paths); err != nil {
+		return err
+	}
+
+	prev := kpr.swap(hostname, slices.Clone(paths))
+
+	for _, path := range prev {
+		if !slices.Contains(paths, path) {
+			kpr.notifier.notify(hostname, path)
+		}
+	}
+
+	return nil
+}
+
+// Replaces path to Unix domain socket for hostname only if the current path is equal
+// to the old one. Returns whether the path was replaced.
+//
+// If the path was replaced with a different one, then subscribers are notified about
+// removal of the old path.
+func (kpr *Keeper) CompareAndSwapPath(hostname, oldPath, newPath string) (bool, error) {
+	if err := isValidPath(newPath); err != nil {
+		return false, err
+	}
+
+	if !kpr.compareAndSwap(hostname, oldPath, newPath) {
+		return false, nil
+	}
+
+	if oldPath != newPath {
+		kpr.notifier.notify(hostname, oldPath)
+	}
+
+	return true, nil
+}
+
+// Removes mapping of hostname and paths to Unix domain sockets.
+//
+// Subscribers are notified about removal of each path.
+func (kpr *Keeper) RemovePath(hostname string) error {
+	paths, exists := kpr.remove(hostname)
+	if !exists {
+		return ErrPathNotFound
+	}
+
+	for _, path := range paths {
+		kpr.notifier.notify(hostname, path)
+	}
+
+	return nil
+}
+
+// Notification of subscribers is performed outside the lock, so modifications are
+// separated from it.
+func (kpr *Keeper) swap(hostname string, paths []string) []string {
+	kpr.mutex.Lock()
+	defer kpr.mutex.Unlock()
+
+	prev, exists := kpr.table.Swap(hostname, paths)
+	if !exists {
+		kpr.length++
+		return nil
+	}
+
+	//nolint:revive,forcetypeassert // Value type is fully controlled
+	return prev.([]string)
+}
+
+// Values are slices that are not comparable, so comparison is performed under
+// the lock that serializes modifications.
+func (kpr *Keeper) compareAndSwap(hostname, oldPath, newPath string) bool {
+	kpr.mutex.Lock()
+	defer kpr.mutex.Unlock()
+
+	paths, exists := kpr.table.Load(hostname)
+	if !exists {
+		return false
+	}
+
+	//nolint:revive,forcetypeassert // Value type is fully controlled
+	if !slices.Equal(paths.([]string), []string{oldPath}) {
+		return false
+	}
+
+	kpr.table.Store(hostname, []string{newPath})
+
+	return true
+}
+
+func (kpr *Keeper) remove(hostname string) ([]string, bool) {
+	kpr.mutex.Lock()
+	defer kpr.mutex.Unlock()
+
+	paths, exists := kpr.table.LoadAndDelete(hostname)
+	if !exists {
+		return nil, false
+	}
+
+	kpr.length--
+
+	//nolint:revive,forcetypeassert // Value type is fully controlled
+	return paths.([]string), true
+}
+
+// Returns number of hostnames mapped to paths to Unix domain sockets.
+func (kpr *Keeper) Len() int {
+	kpr.mutex.RLock()
+	defer kpr.mutex.RUnlock()
+
+	return kpr.length
+}
+
+// Returns a consistent copy of mappings of hostnames and paths to Unix domain sockets.
+//
+// For hostnames mapped to several paths only the first path is returned, use
+// [Keeper.SnapshotPaths] to obtain all of them.
+func (kpr *Keeper) Snapshot() map[string]string {
+	kpr.mutex.RLock()
+	defer kpr.mutex.RUnlock()
+
+	snapshot := make(map[string]string, kpr.length)
+
+	kpr.table.Range(
+		func(hostname, paths any) bool {
+			//nolint:revive,forcetypeassert // Key and value types are fully controlled
+			snapshot[hostname.(string)] = paths.([]string)[0]
+			return true
+		},
+	)
+
+	return snapshot
+}
+
+// Returns a consistent copy of mappings of hostnames and all their paths to Unix
+// domain sockets.
+func (kpr *Keeper) SnapshotPaths() map[string][]string {
+	kpr.mutex.RLock()
+	defer kpr.mutex.RUnlock()
+
+	snapshot := make(map[string][]string, kpr.length)
+
+	kpr.table.Range(
+		func(hostname, paths any) bool {
+			//nolint:revive,forcetypeassert // Key and value types are fully controlled
+			snapshot[hostname.(string)] = slices.Clone(paths.([]string))
+			return true
+		},
+	)
+
+	return snapshot
+}
+
+// Calls yield sequentially for each mapping of hostname and path to Unix domain
+// socket, hostnames mapped to several paths are yielded once for each path. If yield
+// returns false, range stops the iteration.
+//
+// Iteration is performed over a consistent copy of mappings, so yield may modify
+// the keeper.
+func (kpr *Keeper) Range(yield func(hostname, path string) bool) {
+	for hostname, paths := range kpr.SnapshotPaths() {
+		for _, path := range paths {
+			if !yield(hostname, path) {
+				return
+			}
+		}
+	}
+}
+
+// Subscribes to removal of mappings of hostnames and paths to Unix domain sockets.
+//
+// Subscriber is called synchronously after mapping is removed or replaced. Returns
+// function that cancels the subscription.
+func (kpr *Keeper) Subscribe(subscriber func(hostname, path string)) func() {
+	return kpr.notifier.subscribe(subscriber)
+}
+
+func isValidMapping(hostname string, paths []string) error {
+	if err := isValidHostname(hostname); err != nil {
+		return err
+	}
+
+	if len(paths) == 0 {
+		return ErrPathEmpty
+	}
+
+	for id, path := range paths {
+		if err := isValidPath(path); err != nil {
+			return err
+		}
+
+		if slices.Contains(paths[:id], path) {
+			return fmt.Errorf("%w: duplicated: %s", ErrPathInvalid, path)
+		}
 	}
 
 	return nil
@@ -39,12 +278,45 @@ func isValidHostname(hostname string) error {
 }
 
 // Resolves path to Unix domain socket by hostname.
+//
+// For hostnames mapped to several paths the first path is returned.
 func (kpr *Keeper) LookupPath(hostname string) (string, error) {
-	path, exists := kpr.table.Load(hostname)
+	paths, exists := kpr.table.Load(hostname)
 	if !exists {
 		return "", ErrPathNotFound
 	}
 
 	//nolint:revive,forcetypeassert // Value type is fully controlled
-	return path.(string), nil
+	return paths.([]string)[0], nil
+}
+
+// Resolves path to Unix domain socket by hostname with respect to the context.
+//
+// For hostnames mapped to several paths the first path is returned.
+func (kpr *Keeper) LookupPathContext(ctx context.Context, hostname string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	return kpr.LookupPath(hostname)
+}
+
+// Resolves all paths to Unix domain sockets by hostname.
+func (kpr *Keeper) LookupPaths(hostname string) ([]string, error) {
+	paths, exists := kpr.table.Load(hostname)
+	if !exists {
+		return nil, ErrPathNotFound
+	}
+
+	//nolint:revive,forcetypeassert // Value type is fully controlled
+	return slices.Clone(paths.([]string)), nil
+}
+
+// Resolves all paths to Unix domain sockets by hostname with respect to the context.
+func (kpr *Keeper) LookupPathsContext(ctx context.Context, hostname string) ([]string, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return kpr.LookupPaths(hostname)
 }
@@ -1,6 +1,7 @@
 package utr
 
 import (
+	"context"
 	"path/filepath"
 	"strconv"
 	"sync/atomic"
@@ -18,6 +19,7 @@ func TestKeeper(t *testing.T) {
 	var keeper Keeper
 
 	require.Error(t, keeper.AddPath(wrongHostname, testSocketPath))
+	require.Error(t, keeper.AddPath(testHostname, ""))
 	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
 	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
 	require.Error(t, keeper.AddPath(testHostname, filepath.Join("dir", testSocketPath)))
@@ -31,6 +33,319 @@ func TestKeeper(t *testing.T) {
 	require.Equal(t, testSocketPath, path)
 }
 
+func TestKeeperLookupPathContext(t *testing.T) {
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+
+	path, err := keeper.LookupPathContext(t.Context(), testHostname)
+	require.NoError(t, err)
+	require.Equal(t, testSocketPath, path)
+
+	path, err = keeper.LookupPathContext(t.Context(), testHostname+testHostname)
+	require.Error(t, err)
+	require.Empty(t, path)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	path, err = keeper.LookupPathContext(ctx, testHostname)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Empty(t, path)
+}
+
+func TestKeeperReplacePath(t *testing.T) {
+	const wrongHostname = "/" + testHostname
+
+	var (
+		keeper  Keeper
+		removed []string
+	)
+
+	keeper.Subscribe(nil)
+	keeper.Subscribe(
+		func(hostname, path string) {
+			require.Equal(t, testHostname, hostname)
+
+			removed = append(removed, path)
+		},
+	)
+
+	otherPath := filepath.Join("dir", testSocketPath)
+
+	require.Error(t, keeper.ReplacePath(wrongHostname, testSocketPath))
+	require.Error(t, keeper.ReplacePath(testHostname, ""))
+	require.NoError(t, keeper.ReplacePath(testHostname, testSocketPath))
+	require.NoError(t, keeper.ReplacePath(testHostname, testSocketPath))
+	require.Empty(t, removed)
+
+	require.NoError(t, keeper.ReplacePath(testHostname, otherPath))
+	require.Equal(t, []string{testSocketPath}, removed)
+
+	path, err := keeper.LookupPath(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, otherPath, path)
+}
+
+func TestKeeperUnsubscribe(t *testing.T) {
+	var (
+		keeper  Keeper
+		removed []string
+		nested  []string
+	)
+
+	keeper.Subscribe(nil)()
+
+	unsubscribe := keeper.Subscribe(
+		func(_, path string) {
+			removed = append(removed, path)
+
+			// Subscribing from the subscriber must not deadlock
+			keeper.Subscribe(func(_, path string) { nested = append(nested, path) })
+		},
+	)
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+	require.NoError(t, keeper.RemovePath(testHostname))
+	require.Equal(t, []string{testSocketPath}, removed)
+	require.Empty(t, nested)
+
+	unsubscribe()
+	unsubscribe()
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+	require.NoError(t, keeper.RemovePath(testHostname))
+	require.Equal(t, []string{testSocketPath}, removed)
+	require.Equal(t, []string{testSocketPath}, nested)
+	require.Len(t, keeper.notifier.subscriptions, 1)
+}
+
+func TestKeeperCompareAndSwapPath(t *testing.T) {
+	var (
+		keeper  Keeper
+		removed []string
+	)
+
+	keeper.Subscribe(
+		func(hostname, path string) {
+			require.Equal(t, testHostname, hostname)
+
+			removed = append(removed, path)
+		},
+	)
+
+	otherPath := filepath.Join("dir", testSocketPath)
+
+	swapped, err := keeper.CompareAndSwapPath(testHostname, testSocketPath, otherPath)
+	require.NoError(t, err)
+	require.False(t, swapped)
+
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+
+	swapped, err = keeper.CompareAndSwapPath(testHostname, otherPath, testSocketPath)
+	require.NoError(t, err)
+	require.False(t, swapped)
+
+	swapped, err = keeper.CompareAndSwapPath(testHostname, testSocketPath, "")
+	require.Error(t, err)
+	require.False(t, swapped)
+
+	swapped, err = keeper.CompareAndSwapPath(testHostname, testSocketPath, testSocketPath)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Empty(t, removed)
+
+	swapped, err = keeper.CompareAndSwapPath(testHostname, testSocketPath, otherPath)
+	require.NoError(t, err)
+	require.True(t, swapped)
+	require.Equal(t, []string{testSocketPath}, removed)
+
+	path, err := keeper.LookupPath(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, otherPath, path)
+}
+
+func TestKeeperRemovePath(t *testing.T) {
+	var (
+		keeper  Keeper
+		removed []string
+	)
+
+	keeper.Subscribe(
+		func(hostname, path string) {
+			require.Equal(t, testHostname, hostname)
+
+			removed = append(removed, path)
+		},
+	)
+
+	require.Error(t, keeper.RemovePath(testHostname))
+	require.NoError(t, keeper.AddPath(testHostname, testSocketPath))
+	require.NoError(t, keeper.RemovePath(testHostname))
+	require.Equal(t, []string{testSocketPath}, removed)
+	require.Error(t, keeper.RemovePath(testHostname))
+
+	path, err := keeper.LookupPath(testHostname)
+	require.Error(t, err)
+	require.Empty(t, path)
+
+	otherPath := filepath.Join("dir", testSocketPath)
+
+	require.NoError(t, keeper.AddPath(testHostname, otherPath))
+
+	path, err = keeper.LookupPath(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, otherPath, path)
+}
+
+func TestKeeperSnapshot(t *testing.T) {
+	const quantity = 10
+
+	var keeper Keeper
+
+	require.Zero(t, keeper.Len())
+	require.Empty(t, keeper.Snapshot())
+
+	expected := make(map[string]string, quantity)
+
+	for id := range quantity {
+		hostname := testHostname + strconv.Itoa(id)
+		path := filepath.Join(strconv.Itoa(id), testSocketPath)
+
+		expected[hostname] = path
+
+		require.NoError(t, keeper.AddPath(hostname, path))
+		require.NoError(t, keeper.AddPath(hostname, path))
+	}
+
+	require.Equal(t, quantity, keeper.Len())
+	require.Equal(t, expected, keeper.Snapshot())
+
+	require.NoError(t, keeper.ReplacePath(testHostname, testSocketPath))
+	require.Equal(t, quantity+1, keeper.Len())
+
+	require.NoError(t, keeper.RemovePath(testHostname))
+	require.Equal(t, quantity, keeper.Len())
+	require.Equal(t, expected, keeper.Snapshot())
+
+	ranged := make(map[string]string, quantity)
+
+	keeper.Range(
+		func(hostname, path string) bool {
+			ranged[hostname] = path
+
+			require.NoError(t, keeper.RemovePath(hostname))
+
+			return true
+		},
+	)
+
+	require.Equal(t, expected, ranged)
+	require.Zero(t, keeper.Len())
+	require.Empty(t, keeper.Snapshot())
+}
+
+func TestKeeperRangeBreak(t *testing.T) {
+	const quantity = 10
+
+	var keeper Keeper
+
+	for id := range quantity {
+		require.NoError(t, keeper.AddPath(testHostname+strconv.Itoa(id), testSocketPath))
+	}
+
+	iterations := 0
+
+	keeper.Range(
+		func(string, string) bool {
+			iterations++
+			return false
+		},
+	)
+
+	require.Equal(t, 1, iterations)
+}
+
+func TestKeeperPaths(t *testing.T) {
+	var (
+		keeper  Keeper
+		removed []string
+	)
+
+	keeper.Subscribe(
+		func(hostname, path string) {
+			require.Equal(t, testHostname, hostname)
+
+			removed = append(removed, path)
+		},
+	)
+
+	require.ErrorIs(t, keeper.AddPaths(testHostname), ErrPathEmpty)
+	require.ErrorIs(t, keeper.AddPaths(testHostname, "first.sock", ""), ErrPathEmpty)
+	require.ErrorIs(t, keeper.AddPaths(testHostname, "first.sock", "first.sock"), ErrPathInvalid)
+	require.ErrorIs(t, keeper.ReplacePaths(testHostname), ErrPathEmpty)
+
+	require.NoError(t, keeper.AddPaths(testHostname, "first.sock", "second.sock"))
+	require.NoError(t, keeper.AddPaths(testHostname, "first.sock", "second.sock"))
+	require.Error(t, keeper.AddPaths(testHostname, "second.sock", "first.sock"))
+	require.Error(t, keeper.AddPath(testHostname, "first.sock"))
+
+	paths, err := keeper.LookupPaths(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first.sock", "second.sock"}, paths)
+
+	paths[0] = "modified.sock"
+
+	paths, err = keeper.LookupPathsContext(t.Context(), testHostname)
+	require.NoError(t, err)
+	require.Equal(t, []string{"first.sock", "second.sock"}, paths)
+
+	path, err := keeper.LookupPath(testHostname)
+	require.NoError(t, err)
+	require.Equal(t, "first.sock", path)
+
+	paths, err = keeper.LookupPaths("nonexistent")
+	require.ErrorIs(t, err, ErrPathNotFound)
+	require.Nil(t, paths)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	paths, err = keeper.LookupPathsContext(ctx, testHostname)
+	require.ErrorIs(t, err, context.Canceled)
+	require.Nil(t, paths)
+
+	swapped, err := keeper.CompareAndSwapPath(testHostname, "first.sock", "third.sock")
+	require.NoError(t, err)
+	require.False(t, swapped)
+
+	require.Equal(t, map[string]string{testHostname: "first.sock"}, keeper.Snapshot())
+	require.Equal(
+		t,
+		map[string][]string{testHostname: {"first.sock", "second.sock"}},
+		keeper.SnapshotPaths(),
+	)
+
+	var ranged []string
+
+	keeper.Range(
+		func(_, path string) bool {
+			ranged = append(ranged, path)
+			return true
+		},
+	)
+
+	require.Equal(t, []string{"first.sock", "second.sock"}, ranged)
+
+	require.NoError(t, keeper.ReplacePaths(testHostname, "second.sock", "third.sock"))
+	require.Equal(t, []string{"first.sock"}, removed)
+	require.Equal(t, 1, keeper.Len())
+
+	require.NoError(t, keeper.RemovePath(testHostname))
+	require.Equal(t, []string{"first.sock", "second.sock", "third.sock"}, removed)
+	require.Zero(t, keeper.Len())
+}
+
 func BenchmarkAddPathReference(b *testing.B) {
 	table := make(map[string]string)
 
@@ -0,0 +1,254 @@
+package utr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+)
+
+// Provides adjusting of a Unix domain socket listener.
+type ListenAdjuster func(cfg *listenConfig) error
+
+type listenConfig struct {
+	dirMode  fs.FileMode
+	gid      int
+	makeDirs bool
+	mode     fs.FileMode
+	setMode  bool
+	setOwner bool
+	uid      int
+}
+
+// Sets file mode of Unix domain socket.
+func WithFileMode(mode fs.FileMode) ListenAdjuster {
+	adj := func(cfg *listenConfig) error {
+		if mode&^fs.ModePerm != 0 {
+			return fmt.Errorf("%w: %s", ErrFileModeInvalid, mode)
+		}
+
+		cfg.mode = mode
+		cfg.setMode = true
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets owner and group of Unix domain socket.
+func WithOwner(uid, gid int) ListenAdjuster {
+	adj := func(cfg *listenConfig) error {
+		cfg.uid = uid
+		cfg.gid = gid
+		cfg.setOwner = true
+
+		return nil
+	}
+
+	return adj
+}
+
+// Enables creation of missing parent directories of Unix domain socket with
+// specified file mode.
+func WithParentDirs(mode fs.FileMode) ListenAdjuster {
+	adj := func(cfg *listenConfig) error {
+		if mode&^fs.ModePerm != 0 {
+			return fmt.Errorf("%w: %s", ErrFileModeInvalid, mode)
+		}
+
+		cfg.dirMode = mode
+		cfg.makeDirs = true
+
+		return nil
+	}
+
+	return adj
+}
+
+// Creates listener of Unix domain socket.
+//
+// If file of Unix domain socket left by a crashed process exists and nothing
+// listens on it, then the file is removed. If something listens on it, then
+// [ErrSocketInUse] is returned. If the file is not Unix domain socket, then
+// [ErrPathNotSocket] is returned.
+//
+// If file mode or owner of Unix domain socket is specified, then the socket is created
+// in a temporary directory accessible only by the owner, that is created in the parent
+// directory, adjusted there and then moved to the path, so it cannot be connected to
+// before it is adjusted. Otherwise file mode and owner are determined by the umask and
+// the credentials of the process.
+//
+// File of Unix domain socket is removed when the listener is closed.
+//
+// On Linux, path can be an address in the abstract namespace, which starts with '@' or
+// NUL character. In this case filesystem related options are ignored.
+func Listen(ctx context.Context, path string, opts ...ListenAdjuster) (net.Listener, error) {
+	if err := isValidPath(path); err != nil {
+		return nil, err
+	}
+
+	cfg := listenConfig{}
+
+	for _, adj := range opts {
+		if err := adj(&cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	if isAbstractPath(path) {
+		return listenPlain(ctx, path)
+	}
+
+	if cfg.makeDirs {
+		if err := os.MkdirAll(filepath.Dir(path), cfg.dirMode); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := removeStaleSocket(ctx, path); err != nil {
+		return nil, err
+	}
+
+	if !cfg.setMode && !cfg.setOwner {
+		return listenPlain(ctx, path)
+	}
+
+	return listenAdjusted(ctx, path, cfg)
+}
+
+// Avoids returning of nil listener of concrete type as non-nil interface.
+func listenPlain(ctx context.Context, path string) (net.Listener, error) {
+	listener, err := listenUnix(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	return listener, nil
+}
+
+// Listener of Unix domain socket that was moved after creation, so the file is removed
+// by the path to which it was moved.
+type movedListener struct {
+	*net.UnixListener
+
+	once sync.Once
+	path string
+}
+
+func (lst *movedListener) Addr() net.Addr {
+	return &net.UnixAddr{Name: lst.path, Net: unixNetworkName}
+}
+
+func (lst *movedListener) Close() error {
+	err := lst.UnixListener.Close()
+
+	lst.once.Do(func() { _ = os.Remove(lst.path) })
+
+	return err
+}
+
+func listenAdjusted(ctx context.Context, path string, cfg listenConfig) (net.Listener, error) {
+	// Name of the directory is kept short due to the limit of the socket path length
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".utr")
+	if err != nil {
+		return nil, err
+	}
+
+	defer os.RemoveAll(dir)
+
+	temp := filepath.Join(dir, "s")
+
+	listener, err := listenUnix(ctx, temp)
+	if err != nil {
+		return nil, err
+	}
+
+	listener.SetUnlinkOnClose(false)
+
+	if err := cfg.apply(temp); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	if err := os.Rename(temp, path); err != nil {
+		_ = listener.Close()
+		return nil, err
+	}
+
+	moved := &movedListener{
+		UnixListener: listener,
+		path:         path,
+	}
+
+	return moved, nil
+}
+
+func listenUnix(ctx context.Context, path string) (*net.UnixListener, error) {
+	var blank net.ListenConfig
+
+	listener, err := blank.Listen(ctx, unixNetworkName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	//nolint:revive,forcetypeassert // Listener type is determined by the network
+	unixListener := listener.(*net.UnixListener)
+	unixListener.SetUnlinkOnClose(true)
+
+	return unixListener, nil
+}
+
+func removeStaleSocket(ctx context.Context, path string) error {
+	info, err := os.Lstat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+
+		return err
+	}
+
+	if info.Mode().Type() != fs.ModeSocket {
+		return fmt.Errorf("%w: %s", ErrPathNotSocket, path)
+	}
+
+	var dialer net.Dialer
+
+	conn, err := dialer.DialContext(ctx, unixNetworkName, path)
+	if err == nil {
+		_ = conn.Close()
+		return fmt.Errorf("%w: %s", ErrSocketInUse, path)
+	}
+
+	if !errors.Is(err, syscall.ECONNREFUSED) {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	return nil
+}
+
+func (cfg listenConfig) apply(path string) error {
+	if cfg.setOwner {
+		if err := os.Chown(path, cfg.uid, cfg.gid); err != nil {
+			return err
+		}
+	}
+
+	if cfg.setMode {
+		if err := os.Chmod(path, cfg.mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
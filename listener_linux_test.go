@@ -0,0 +1,21 @@
+package utr
+
+import (
+	"crypto/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListenAbstract(t *testing.T) {
+	path := "@" + testHostname + "-" + rand.Text()
+
+	listener, err := Listen(t.Context(), path, WithFileMode(0o600), WithParentDirs(0o700))
+	require.NoError(t, err)
+
+	occupied, err := Listen(t.Context(), path)
+	require.Error(t, err)
+	require.Nil(t, occupied)
+
+	require.NoError(t, listener.Close())
+}
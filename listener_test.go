@@ -0,0 +1,122 @@
+package utr
+
+import (
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithFileMode(t *testing.T) {
+	cfg := listenConfig{}
+
+	require.Error(t, WithFileMode(fs.ModeDir|0o700)(&cfg))
+	require.False(t, cfg.setMode)
+
+	require.NoError(t, WithFileMode(0o660)(&cfg))
+	require.True(t, cfg.setMode)
+	require.Equal(t, fs.FileMode(0o660), cfg.mode)
+}
+
+func TestWithParentDirs(t *testing.T) {
+	cfg := listenConfig{}
+
+	require.Error(t, WithParentDirs(fs.ModeDir|0o700)(&cfg))
+	require.False(t, cfg.makeDirs)
+
+	require.NoError(t, WithParentDirs(0o750)(&cfg))
+	require.True(t, cfg.makeDirs)
+	require.Equal(t, fs.FileMode(0o750), cfg.dirMode)
+}
+
+func TestListen(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "parent", "dir", testSocketPath)
+
+	listener, err := Listen(t.Context(), socketPath)
+	require.Error(t, err)
+	require.Nil(t, listener)
+
+	listener, err = Listen(
+		t.Context(),
+		socketPath,
+		WithParentDirs(0o750),
+		WithFileMode(0o600),
+		WithOwner(os.Getuid(), os.Getgid()),
+	)
+	require.NoError(t, err)
+
+	info, err := os.Stat(socketPath)
+	require.NoError(t, err)
+	require.Equal(t, fs.ModeSocket, info.Mode().Type())
+	require.Equal(t, fs.FileMode(0o600), info.Mode().Perm())
+
+	require.Equal(t, socketPath, listener.Addr().String())
+
+	// Mode of created directory is limited by the umask
+	info, err = os.Stat(filepath.Dir(socketPath))
+	require.NoError(t, err)
+	require.Zero(t, info.Mode().Perm()&^fs.FileMode(0o750))
+
+	// Temporary directory is removed
+	entries, err := os.ReadDir(filepath.Dir(socketPath))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	require.Equal(t, testSocketPath, entries[0].Name())
+
+	occupied, err := Listen(t.Context(), socketPath)
+	require.ErrorIs(t, err, ErrSocketInUse)
+	require.Nil(t, occupied)
+
+	require.NoError(t, listener.Close())
+
+	_, err = os.Stat(socketPath)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestListenStale(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	var blank net.ListenConfig
+
+	stale, err := blank.Listen(t.Context(), unixNetworkName, socketPath)
+	require.NoError(t, err)
+
+	//nolint:forcetypeassert // Listener type is determined by the network
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	_, err = os.Stat(socketPath)
+	require.NoError(t, err)
+
+	listener, err := Listen(t.Context(), socketPath)
+	require.NoError(t, err)
+	require.NoError(t, listener.Close())
+}
+
+func TestListenNotSocket(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), testSocketPath)
+
+	require.NoError(t, os.WriteFile(filePath, nil, 0o600))
+
+	listener, err := Listen(t.Context(), filePath)
+	require.ErrorIs(t, err, ErrPathNotSocket)
+	require.Nil(t, listener)
+
+	_, err = os.Stat(filePath)
+	require.NoError(t, err)
+}
+
+func TestListenBadOpts(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	listener, err := Listen(t.Context(), "")
+	require.Error(t, err)
+	require.Nil(t, listener)
+
+	listener, err = Listen(t.Context(), socketPath, WithFileMode(fs.ModeDir))
+	require.Error(t, err)
+	require.Nil(t, listener)
+}
@@ -1,6 +1,85 @@
 package utr
 
+import "context"
+
 // Resolves path to Unix domain socket by hostname.
 type Resolver interface {
 	LookupPath(hostname string) (string, error)
 }
+
+// Resolves path to Unix domain socket by hostname with respect to the context.
+//
+// If the [Resolver] passed to [New] implements this interface, then the [Transport]
+// uses it with the context of the dial, so resolving is limited by deadline and
+// cancellation of the request.
+type ContextResolver interface {
+	LookupPathContext(ctx context.Context, hostname string) (string, error)
+}
+
+// Resolves several paths to Unix domain sockets by hostname, when hostname is served
+// by several Unix domain sockets.
+//
+// If the [Resolver] passed to [New] implements this interface, then the [Transport]
+// spreads new connections across the paths.
+type MultiResolver interface {
+	LookupPaths(hostname string) ([]string, error)
+}
+
+// Resolves several paths to Unix domain sockets by hostname with respect to
+// the context.
+//
+// If the [Resolver] passed to [New] implements this interface, then the [Transport]
+// uses it with the context of the dial in preference to other interfaces.
+type ContextMultiResolver interface {
+	LookupPathsContext(ctx context.Context, hostname string) ([]string, error)
+}
+
+// Notifies subscribers about removal of mappings of hostnames and paths to Unix domain
+// sockets.
+//
+// If the [Resolver] passed to [New] implements this interface, then the [Transport]
+// subscribes to it and retires connections to the path when a mapping is removed.
+//
+// Subscribe returns function that cancels the subscription.
+type Notifier interface {
+	Subscribe(subscriber func(hostname, path string)) func()
+}
+
+func lookupPath(ctx context.Context, resolver Resolver, hostname string) (string, error) {
+	if ctxResolver, casted := resolver.(ContextResolver); casted {
+		return ctxResolver.LookupPathContext(ctx, hostname)
+	}
+
+	return resolver.LookupPath(hostname)
+}
+
+func lookupPaths(ctx context.Context, resolver Resolver, hostname string) ([]string, error) {
+	var (
+		paths []string
+		err   error
+	)
+
+	switch typed := resolver.(type) {
+	case ContextMultiResolver:
+		paths, err = typed.LookupPathsContext(ctx, hostname)
+	case MultiResolver:
+		paths, err = typed.LookupPaths(hostname)
+	default:
+		path, err := lookupPath(ctx, resolver, hostname)
+		if err != nil {
+			return nil, err
+		}
+
+		return []string{path}, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if len(paths) == 0 {
+		return nil, ErrPathNotFound
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,57 @@
+package utr
+
+import (
+	"slices"
+	"sync"
+)
+
+// Keeps subscribers to removal of mappings of hostnames and paths to Unix domain
+// sockets and notifies them.
+type notifier struct {
+	mutex         sync.RWMutex
+	subscriptions []*subscription
+}
+
+// Pointer to subscription identifies it on cancellation, since functions are not
+// comparable.
+type subscription struct {
+	subscriber func(hostname, path string)
+}
+
+func (ntf *notifier) subscribe(subscriber func(hostname, path string)) func() {
+	if subscriber == nil {
+		return func() {}
+	}
+
+	sub := &subscription{
+		subscriber: subscriber,
+	}
+
+	ntf.mutex.Lock()
+	defer ntf.mutex.Unlock()
+
+	ntf.subscriptions = append(ntf.subscriptions, sub)
+
+	unsubscribe := func() {
+		ntf.mutex.Lock()
+		defer ntf.mutex.Unlock()
+
+		ntf.subscriptions = slices.DeleteFunc(
+			ntf.subscriptions,
+			func(current *subscription) bool { return current == sub },
+		)
+	}
+
+	return unsubscribe
+}
+
+// Subscribers are called outside the lock, so they can subscribe and unsubscribe.
+func (ntf *notifier) notify(hostname, path string) {
+	ntf.mutex.RLock()
+	subscriptions := slices.Clone(ntf.subscriptions)
+	ntf.mutex.RUnlock()
+
+	for _, sub := range subscriptions {
+		sub.subscriber(hostname, path)
+	}
+}
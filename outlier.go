@@ -0,0 +1,215 @@
+package utr
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+// Maximum factor by which ejection backoff is increased on consecutive ejections.
+const maxOutlierBackoffFactor = 32
+
+// Enables passive detection of outlier paths to Unix domain sockets.
+//
+// Consecutive failures of dialing and of requests are counted for each path, successful
+// request resets the counter. Failures caused by cancellation of the request context
+// are not counted. When number of consecutive failures reaches the threshold, then
+// the path is ejected for the backoff period and is not dialed. After the period
+// a single trial connection is allowed to the path, if the request sent over it
+// succeeds, then the path is restored, otherwise the path is ejected again with
+// doubled backoff, but not more than 32 times the specified one.
+//
+// When the path is ejected, then idle connections to it are closed and connections
+// that are in use are closed after completion of the requests.
+//
+// If all paths of a hostname are ejected, then dialing fails immediately with
+// [ErrPathEjected].
+func WithOutlierDetection(threshold int, backoff time.Duration) Adjuster {
+	adj := func(trt *Transport) error {
+		if threshold <= 0 {
+			return fmt.Errorf("%w: %d", ErrThresholdInvalid, threshold)
+		}
+
+		if backoff <= 0 {
+			return fmt.Errorf("%w: %s", ErrBackoffInvalid, backoff)
+		}
+
+		trt.outliers = newOutlierDetector(threshold, backoff)
+
+		return nil
+	}
+
+	return adj
+}
+
+// Tracks failures of paths to Unix domain sockets and ejects outliers. Nil detector
+// allows all paths.
+type outlierDetector struct {
+	backoff   time.Duration
+	now       func() time.Time
+	threshold int
+
+	// Called outside the lock when the path is ejected
+	onEject func(path string)
+
+	mutex  sync.Mutex
+	states map[string]*outlierState
+}
+
+type outlierState struct {
+	// Consecutive failures
+	failures int
+	// Consecutive ejections, determines backoff
+	ejections int
+	// Path is ejected until, zero if path is not ejected
+	until time.Time
+	// Ejection period is over and trial connection is allowed
+	trial bool
+}
+
+func newOutlierDetector(threshold int, backoff time.Duration) *outlierDetector {
+	otd := &outlierDetector{
+		backoff:   backoff,
+		now:       time.Now,
+		threshold: threshold,
+
+		states: make(map[string]*outlierState),
+	}
+
+	return otd
+}
+
+// Returns whether path can be dialed. When ejection period of the path is over, only
+// a single trial is allowed, next trial is allowed if the result of the current one is
+// not reported within the backoff.
+func (otd *outlierDetector) allow(path string) bool {
+	if otd == nil {
+		return true
+	}
+
+	otd.mutex.Lock()
+	defer otd.mutex.Unlock()
+
+	state := otd.states[path]
+	if state == nil || state.until.IsZero() {
+		return true
+	}
+
+	now := otd.now()
+
+	if now.Before(state.until) {
+		return false
+	}
+
+	state.until = now.Add(otd.ejectionBackoff(state.ejections))
+	state.trial = true
+
+	return true
+}
+
+// Returns whether path is ejected and its ejection period is not over. Unlike
+// the allow method, trial is not consumed.
+func (otd *outlierDetector) isEjected(path string) bool {
+	if otd == nil {
+		return false
+	}
+
+	otd.mutex.Lock()
+	defer otd.mutex.Unlock()
+
+	state := otd.states[path]
+	if state == nil || state.until.IsZero() || state.trial {
+		return false
+	}
+
+	return otd.now().Before(state.until)
+}
+
+// Reports failure of dialing of the path or result of the request sent over
+// a connection to it.
+func (otd *outlierDetector) report(ctx context.Context, path string, err error) {
+	if otd == nil {
+		return
+	}
+
+	if err == nil {
+		otd.succeed(path)
+		return
+	}
+
+	if errors.Is(ctx.Err(), context.Canceled) {
+		return
+	}
+
+	otd.fail(path)
+}
+
+// Reports result of the request sent over the connection or failure of
+// establishing of it.
+func (otd *outlierDetector) reportConn(ctx context.Context, conn net.Conn, err error) {
+	if path, found := getConnPath(conn); found {
+		otd.report(ctx, path, err)
+	}
+}
+
+func (otd *outlierDetector) succeed(path string) {
+	otd.mutex.Lock()
+	defer otd.mutex.Unlock()
+
+	delete(otd.states, path)
+}
+
+func (otd *outlierDetector) fail(path string) {
+	if otd.eject(path) && otd.onEject != nil {
+		otd.onEject(path)
+	}
+}
+
+// Counts failure of the path and returns whether the path has been ejected.
+func (otd *outlierDetector) eject(path string) bool {
+	otd.mutex.Lock()
+	defer otd.mutex.Unlock()
+
+	state := otd.states[path]
+	if state == nil {
+		state = &outlierState{}
+		otd.states[path] = state
+	}
+
+	state.failures++
+
+	if state.until.IsZero() && state.failures < otd.threshold {
+		return false
+	}
+
+	state.ejections++
+	state.until = otd.now().Add(otd.ejectionBackoff(state.ejections))
+	state.trial = false
+
+	return true
+}
+
+func (otd *outlierDetector) ejectionBackoff(ejections int) time.Duration {
+	factor := 1
+
+	for range ejections - 1 {
+		if factor >= maxOutlierBackoffFactor {
+			break
+		}
+
+		factor *= 2
+	}
+
+	return time.Duration(factor) * otd.backoff
+}
+
+func getConnPath(conn net.Conn) (string, bool) {
+	if tracked := getTrackedConn(conn); tracked != nil {
+		return tracked.path, true
+	}
+
+	return "", false
+}
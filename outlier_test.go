@@ -0,0 +1,380 @@
+package utr
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithOutlierDetection(t *testing.T) {
+	trt := &Transport{}
+
+	require.Error(t, WithOutlierDetection(0, time.Second)(trt))
+	require.Error(t, WithOutlierDetection(1, 0)(trt))
+	require.Nil(t, trt.outliers)
+
+	require.NoError(t, WithOutlierDetection(1, time.Second)(trt))
+	require.NotNil(t, trt.outliers)
+}
+
+func TestOutlierDetector(t *testing.T) {
+	const backoff = time.Minute
+
+	clock := newTestClock()
+
+	otd := newOutlierDetector(2, backoff)
+	otd.now = clock.Now
+
+	var ejected []string
+
+	otd.onEject = func(path string) {
+		ejected = append(ejected, path)
+	}
+
+	otd.report(t.Context(), testSocketPath, syscall.ECONNREFUSED)
+	require.True(t, otd.allow(testSocketPath))
+
+	otd.report(t.Context(), testSocketPath, nil)
+	otd.report(t.Context(), testSocketPath, syscall.ECONNREFUSED)
+	require.True(t, otd.allow(testSocketPath))
+
+	require.False(t, otd.isEjected(testSocketPath))
+	require.Empty(t, ejected)
+
+	otd.report(t.Context(), testSocketPath, syscall.ECONNREFUSED)
+	require.True(t, otd.isEjected(testSocketPath))
+	require.False(t, otd.allow(testSocketPath))
+	require.Equal(t, []string{testSocketPath}, ejected)
+
+	require.False(t, otd.isEjected("other.sock"))
+	require.True(t, otd.allow("other.sock"))
+
+	clock.Advance(backoff - time.Nanosecond)
+	require.True(t, otd.isEjected(testSocketPath))
+	require.False(t, otd.allow(testSocketPath))
+
+	clock.Advance(time.Nanosecond)
+	require.False(t, otd.isEjected(testSocketPath))
+
+	// Single trial
+	require.True(t, otd.allow(testSocketPath))
+	require.False(t, otd.allow(testSocketPath))
+	require.False(t, otd.isEjected(testSocketPath))
+
+	otd.report(t.Context(), testSocketPath, syscall.ECONNREFUSED)
+	require.True(t, otd.isEjected(testSocketPath))
+	require.Equal(t, []string{testSocketPath, testSocketPath}, ejected)
+
+	// Backoff is doubled
+	clock.Advance(backoff)
+	require.False(t, otd.allow(testSocketPath))
+
+	clock.Advance(backoff)
+	require.True(t, otd.allow(testSocketPath))
+
+	// Next trial is allowed if result of the current one is not reported
+	// within the backoff
+	clock.Advance(2*backoff - time.Nanosecond)
+	require.False(t, otd.allow(testSocketPath))
+
+	clock.Advance(time.Nanosecond)
+	require.True(t, otd.allow(testSocketPath))
+
+	otd.report(t.Context(), testSocketPath, nil)
+	require.True(t, otd.allow(testSocketPath))
+	require.True(t, otd.allow(testSocketPath))
+	require.Empty(t, otd.states)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	cancel()
+
+	otd.report(ctx, testSocketPath, context.Canceled)
+	otd.report(ctx, testSocketPath, context.Canceled)
+	require.True(t, otd.allow(testSocketPath))
+	require.Len(t, ejected, 2)
+}
+
+func TestOutlierDetectorBackoff(t *testing.T) {
+	otd := newOutlierDetector(1, time.Second)
+
+	require.Equal(t, time.Second, otd.ejectionBackoff(1))
+	require.Equal(t, 2*time.Second, otd.ejectionBackoff(2))
+	require.Equal(t, 32*time.Second, otd.ejectionBackoff(6))
+	require.Equal(t, 32*time.Second, otd.ejectionBackoff(100))
+}
+
+func TestOutlierDetectorNil(t *testing.T) {
+	var otd *outlierDetector
+
+	otd.report(t.Context(), testSocketPath, syscall.ECONNREFUSED)
+	require.True(t, otd.allow(testSocketPath))
+	require.False(t, otd.isEjected(testSocketPath))
+}
+
+func TestGetConnPath(t *testing.T) {
+	client, server := net.Pipe()
+
+	defer func() {
+		require.NoError(t, client.Close())
+		require.NoError(t, server.Close())
+	}()
+
+	path, found := getConnPath(client)
+	require.False(t, found)
+	require.Empty(t, path)
+
+	path, found = getConnPath(newTrackedConn(client, testSocketPath, nil))
+	require.True(t, found)
+	require.Equal(t, testSocketPath, path)
+}
+
+func TestTransportOutlierDialing(t *testing.T) {
+	refused := filepath.Join(t.TempDir(), testSocketPath)
+
+	var blank net.ListenConfig
+
+	stale, err := blank.Listen(t.Context(), unixNetworkName, refused)
+	require.NoError(t, err)
+
+	//nolint:forcetypeassert // Listener type is determined by the network
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	trt, err := New(
+		pathsResolver{refused},
+		cloneDefaultHTTPTransport(t),
+		WithOutlierDetection(2, time.Minute),
+	)
+	require.NoError(t, err)
+
+	for range 2 {
+		conn, err := trt.dial(t.Context(), "", testHostname+":80")
+		require.ErrorIs(t, err, syscall.ECONNREFUSED)
+		require.Nil(t, conn)
+	}
+
+	conn, err := trt.dial(t.Context(), "", testHostname+":80")
+	require.ErrorIs(t, err, ErrPathEjected)
+	require.Nil(t, conn)
+}
+
+func TestTransportOutlierRequests(t *testing.T) {
+	const backoff = time.Minute
+
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	listener, err := Listen(t.Context(), socketPath)
+	require.NoError(t, err)
+
+	var failing atomic.Bool
+
+	failing.Store(true)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				if !failing.Load() {
+					return
+				}
+
+				//nolint:forcetypeassert // Writer of HTTP/1 server is a hijacker
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err != nil {
+					return
+				}
+
+				_ = conn.Close()
+			},
+		),
+		ReadTimeout: time.Second,
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	defer func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}()
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+
+	httpTransport := cloneDefaultHTTPTransport(t)
+	httpTransport.DisableKeepAlives = true
+
+	trt, err := New(&keeper, httpTransport, WithOutlierDetection(1, backoff))
+	require.NoError(t, err)
+
+	clock := newTestClock()
+	trt.outliers.now = clock.Now
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   testHostname,
+	}
+
+	do := func() error {
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		if err != nil {
+			return err
+		}
+
+		return resp.Body.Close()
+	}
+
+	require.Error(t, do())
+	require.ErrorIs(t, do(), ErrPathEjected)
+
+	failing.Store(false)
+	clock.Advance(backoff)
+
+	require.NoError(t, do())
+	require.NoError(t, do())
+	require.Empty(t, trt.outliers.states)
+}
+
+func TestTransportOutlierConnections(t *testing.T) {
+	const backoff = time.Minute
+
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	var blank net.ListenConfig
+
+	listener, err := blank.Listen(t.Context(), unixNetworkName, socketPath)
+	require.NoError(t, err)
+
+	var accepted, closed atomic.Int64
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(
+			func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = w.Write([]byte("response"))
+			},
+		),
+		ReadTimeout: time.Second,
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			switch state {
+			case http.StateNew:
+				accepted.Add(1)
+			case http.StateClosed:
+				closed.Add(1)
+			default:
+			}
+		},
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	defer func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}()
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+
+	trt, err := New(&keeper, cloneDefaultHTTPTransport(t), WithOutlierDetection(1, backoff))
+	require.NoError(t, err)
+
+	defer trt.Close()
+
+	clock := newTestClock()
+	trt.outliers.now = clock.Now
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   testHostname,
+	}
+
+	do := func() *http.Response {
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		return resp
+	}
+
+	// Body of the first response is not read, so its connection stays in use
+	// and the second request establishes another connection, that becomes idle
+	inUse := do()
+
+	idle := do()
+	_, err = io.Copy(io.Discard, idle.Body)
+	require.NoError(t, err)
+	require.NoError(t, idle.Body.Close())
+
+	require.Equal(t, int64(2), accepted.Load())
+	require.Zero(t, closed.Load())
+
+	trt.outliers.report(t.Context(), socketPath, syscall.ECONNREFUSED)
+
+	// Only idle connection is closed on ejection
+	require.Eventually(
+		t,
+		func() bool { return closed.Load() == 1 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	// Connection in use is closed after completion of the request
+	_, err = io.Copy(io.Discard, inUse.Body)
+	require.NoError(t, err)
+	require.NoError(t, inUse.Body.Close())
+
+	require.Eventually(
+		t,
+		func() bool { return closed.Load() == 2 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	clock.Advance(backoff)
+
+	resp := do()
+	require.NoError(t, resp.Body.Close())
+	require.Equal(t, int64(3), accepted.Load())
+	require.Empty(t, trt.outliers.states)
+}
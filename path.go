@@ -0,0 +1,57 @@
+package utr
+
+import (
+	"fmt"
+	"strings"
+	"syscall"
+)
+
+const (
+	// Size of the path field of the address of Unix domain socket.
+	maxPathSize = len(syscall.RawSockaddrUnix{}.Path)
+
+	// Prefixes of addresses of Unix domain sockets in the Linux abstract namespace.
+	abstractPrefix    = '@'
+	abstractPrefixNUL = '\x00'
+)
+
+func isValidPath(path string) error {
+	if path == "" {
+		return ErrPathEmpty
+	}
+
+	if isAbstractPath(path) {
+		return isValidAbstractPath(path)
+	}
+
+	if strings.IndexByte(path, abstractPrefixNUL) != -1 {
+		return fmt.Errorf("%w: contains NUL character", ErrPathInvalid)
+	}
+
+	// Path to Unix domain socket in the filesystem is terminated with NUL character
+	if len(path) >= maxPathSize {
+		return fmt.Errorf("%w: too long", ErrPathInvalid)
+	}
+
+	return nil
+}
+
+func isValidAbstractPath(path string) error {
+	if !isAbstractSupported {
+		return fmt.Errorf("%w: abstract namespace is not supported", ErrPathInvalid)
+	}
+
+	if len(path) == 1 {
+		return fmt.Errorf("%w: name in abstract namespace is empty", ErrPathInvalid)
+	}
+
+	if len(path) > maxPathSize {
+		return fmt.Errorf("%w: too long", ErrPathInvalid)
+	}
+
+	return nil
+}
+
+func isAbstractPath(path string) bool {
+	return path[0] == abstractPrefix || path[0] == abstractPrefixNUL
+}
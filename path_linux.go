@@ -0,0 +1,3 @@
+package utr
+
+const isAbstractSupported = true
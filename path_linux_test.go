@@ -0,0 +1,94 @@
+package utr
+
+import (
+	"crypto/rand"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportAbstract(t *testing.T) {
+	name := testHostname + "-" + rand.Text()
+
+	testTransportAbstractBase(t, "@"+name, "@"+name)
+	testTransportAbstractBase(t, "@"+name, "\x00"+name)
+	testTransportAbstractBase(t, "\x00"+name, "@"+name)
+}
+
+func testTransportAbstractBase(t *testing.T, listenPath, dialPath string) {
+	const requestPath = "/request/path"
+
+	message := prepareMessage(t)
+
+	var router http.ServeMux
+
+	router.HandleFunc(
+		requestPath,
+		func(w http.ResponseWriter, _ *http.Request) {
+			_, _ = w.Write(message)
+		},
+	)
+
+	server := &http.Server{
+		Handler:     &router,
+		ReadTimeout: time.Second,
+	}
+
+	serverErr := make(chan error)
+	defer close(serverErr)
+
+	var blank net.ListenConfig
+
+	listener, err := blank.Listen(t.Context(), unixNetworkName, listenPath)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}()
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, dialPath))
+
+	trt, err := New(&keeper, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   testHostname,
+		Path:   requestPath,
+	}
+
+	request, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodGet,
+		requestURL.String(),
+		http.NoBody,
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Do(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	output, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.Equal(t, message, output)
+	require.NoError(t, resp.Body.Close())
+
+	client.CloseIdleConnections()
+}
@@ -0,0 +1,34 @@
+package utr
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsValidPath(t *testing.T) {
+	require.NoError(t, isValidPath(testSocketPath))
+	require.NoError(t, isValidPath("/run/"+testSocketPath))
+	require.NoError(t, isValidPath(strings.Repeat("a", maxPathSize-1)))
+
+	require.ErrorIs(t, isValidPath(""), ErrPathEmpty)
+	require.ErrorIs(t, isValidPath("dir\x00"+testSocketPath), ErrPathInvalid)
+	require.ErrorIs(t, isValidPath(strings.Repeat("a", maxPathSize)), ErrPathInvalid)
+
+	require.ErrorIs(t, isValidPath("@"), ErrPathInvalid)
+	require.ErrorIs(t, isValidPath("\x00"), ErrPathInvalid)
+	require.ErrorIs(t, isValidPath("@"+strings.Repeat("a", maxPathSize)), ErrPathInvalid)
+
+	if !isAbstractSupported {
+		require.ErrorIs(t, isValidPath("@"+testHostname), ErrPathInvalid)
+		require.ErrorIs(t, isValidPath("\x00"+testHostname), ErrPathInvalid)
+
+		return
+	}
+
+	require.NoError(t, isValidPath("@"+testHostname))
+	require.NoError(t, isValidPath("\x00"+testHostname))
+	require.NoError(t, isValidPath("@"+strings.Repeat("a", maxPathSize-1)))
+	require.NoError(t, isValidPath("\x00"+testHostname+"\x00"))
+}
@@ -0,0 +1,86 @@
+package utr
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Sets maximum number of idle connections to Unix domain sockets across all
+// hostnames, zero means no limit.
+//
+// If limit is not set, then the limit of the upstream [http.Transport] will be used.
+func WithMaxIdleConns(limit int) Adjuster {
+	adj := func(trt *Transport) error {
+		if limit < 0 {
+			return fmt.Errorf("%w: %d", ErrLimitInvalid, limit)
+		}
+
+		trt.addPoolSetting(func(base *http.Transport) { base.MaxIdleConns = limit })
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets maximum number of idle connections to Unix domain sockets per hostname, zero
+// means [http.DefaultMaxIdleConnsPerHost].
+//
+// If limit is not set, then the limit of the upstream [http.Transport] will be used.
+func WithMaxIdleConnsPerHost(limit int) Adjuster {
+	adj := func(trt *Transport) error {
+		if limit < 0 {
+			return fmt.Errorf("%w: %d", ErrLimitInvalid, limit)
+		}
+
+		trt.addPoolSetting(func(base *http.Transport) { base.MaxIdleConnsPerHost = limit })
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets maximum number of connections to Unix domain sockets per hostname, including
+// connections in the dialing, active, and idle states, zero means no limit.
+//
+// If limit is not set, then the limit of the upstream [http.Transport] will be used.
+func WithMaxConnsPerHost(limit int) Adjuster {
+	adj := func(trt *Transport) error {
+		if limit < 0 {
+			return fmt.Errorf("%w: %d", ErrLimitInvalid, limit)
+		}
+
+		trt.addPoolSetting(func(base *http.Transport) { base.MaxConnsPerHost = limit })
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets maximum amount of time an idle connection to Unix domain socket will remain
+// idle before closing itself, zero means no limit.
+//
+// If timeout is not set, then the timeout of the upstream [http.Transport] will be
+// used.
+func WithIdleConnTimeout(timeout time.Duration) Adjuster {
+	adj := func(trt *Transport) error {
+		if timeout < 0 {
+			return fmt.Errorf("%w: %s", ErrTimeoutInvalid, timeout)
+		}
+
+		trt.addPoolSetting(func(base *http.Transport) { base.IdleConnTimeout = timeout })
+
+		return nil
+	}
+
+	return adj
+}
+
+// Settings are applied to the clone of the upstream transport after it is created in
+// [New], so they do not affect the upstream transport.
+func (trt *Transport) addPoolSetting(setting func(base *http.Transport)) {
+	trt.poolSettings = append(trt.poolSettings, setting)
+}
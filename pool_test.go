@@ -0,0 +1,59 @@
+package utr
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolAdjustersBad(t *testing.T) {
+	for _, adj := range []Adjuster{
+		WithMaxIdleConns(-1),
+		WithMaxIdleConnsPerHost(-1),
+		WithMaxConnsPerHost(-1),
+		WithIdleConnTimeout(-time.Second),
+	} {
+		trt := &Transport{}
+
+		require.Error(t, adj(trt))
+		require.Empty(t, trt.poolSettings)
+	}
+}
+
+func TestTransportPool(t *testing.T) {
+	upstream := cloneDefaultHTTPTransport(t)
+	upstream.MaxConnsPerHost = 10
+
+	trt, err := New(
+		&Keeper{},
+		upstream,
+		WithMaxIdleConns(1),
+		WithMaxIdleConnsPerHost(2),
+		WithIdleConnTimeout(time.Second),
+		WithIdleConnTimeout(0),
+	)
+	require.NoError(t, err)
+
+	require.Equal(t, 1, trt.base.MaxIdleConns)
+	require.Equal(t, 2, trt.base.MaxIdleConnsPerHost)
+	require.Equal(t, 10, trt.base.MaxConnsPerHost)
+	require.Zero(t, trt.base.IdleConnTimeout)
+
+	defaultTransport := cloneDefaultHTTPTransport(t)
+
+	require.Equal(t, defaultTransport.MaxIdleConns, upstream.MaxIdleConns)
+	require.Equal(t, defaultTransport.MaxIdleConnsPerHost, upstream.MaxIdleConnsPerHost)
+	require.Equal(t, defaultTransport.IdleConnTimeout, upstream.IdleConnTimeout)
+
+	trt, err = New(&Keeper{}, upstream, WithMaxConnsPerHost(0))
+	require.NoError(t, err)
+	require.Zero(t, trt.base.MaxConnsPerHost)
+	require.Equal(t, 10, upstream.MaxConnsPerHost)
+	require.Equal(t, upstream.MaxIdleConns, trt.base.MaxIdleConns)
+
+	trt, err = New(&Keeper{}, &http.Transport{}, WithMaxConnsPerHost(3))
+	require.NoError(t, err)
+	require.Equal(t, 3, trt.base.MaxConnsPerHost)
+}
@@ -0,0 +1,71 @@
+package utr
+
+import (
+	"crypto/tls"
+	"net"
+)
+
+// TLS client configuration for operation HTTPS via Unix domain socket and number of
+// its replacements.
+type tlsConfigState struct {
+	config     *tls.Config
+	generation uint64
+}
+
+// Replaces TLS client configuration for operation HTTPS via Unix domain socket, e.g.
+// to rotate client certificates. Configuration is copied, nil configuration means
+// default one. If application protocols are not specified in the configuration, then
+// protocols of the upstream transport are negotiated, e.g. HTTP/2.
+//
+// Connections established with the previous configuration are retired as described in
+// [Transport.RotateTLS].
+func (trt *Transport) SetTLSConfig(config *tls.Config) {
+	config = config.Clone()
+
+	trt.swapTLS(func(*tls.Config) *tls.Config { return config })
+}
+
+// Retires connections established with the current TLS client configuration, e.g.
+// when certificates provided by the function set using [WithTLSConfigFunc] function or
+// by GetClientCertificate function of the configuration are rotated.
+//
+// Idle connections are closed immediately. HTTP/1 connections that are in use are
+// closed after completion of the current or of the next request over them, HTTP/2
+// connections that are in use are kept.
+func (trt *Transport) RotateTLS() {
+	trt.swapTLS(func(current *tls.Config) *tls.Config { return current })
+}
+
+func (trt *Transport) swapTLS(next func(current *tls.Config) *tls.Config) {
+	for {
+		current := trt.tlsState.Load()
+
+		swapped := &tlsConfigState{
+			config:     next(current.config),
+			generation: current.generation + 1,
+		}
+
+		if trt.tlsState.CompareAndSwap(current, swapped) {
+			break
+		}
+	}
+
+	trt.base.CloseIdleConnections()
+}
+
+// Returns whether the connection is established with TLS configuration that is
+// already replaced.
+func (trt *Transport) isStaleTLS(conn net.Conn) bool {
+	tlsConn, casted := conn.(*tls.Conn)
+	if !casted {
+		return false
+	}
+
+	var generation uint64
+
+	if tracked, casted := tlsConn.NetConn().(*trackedConn); casted {
+		generation = tracked.generation
+	}
+
+	return generation != trt.tlsState.Load().generation
+}
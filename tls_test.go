@@ -0,0 +1,234 @@
+package utr
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportSetTLSConfig(t *testing.T) {
+	testTransportSetTLSConfigBase(t, false)
+}
+
+func TestTransportSetTLSConfigHTTP2(t *testing.T) {
+	testTransportSetTLSConfigBase(t, true)
+}
+
+func testTransportSetTLSConfigBase(t *testing.T, useHTTP2 bool) {
+	caPool, serverCerts, firstCerts := genTempPKI(t, testHostname)
+	_, _, secondCerts := genTempPKI(t, testHostname)
+
+	client, proto, stop := prepareTLSRotation(
+		t,
+		useHTTP2,
+		serverCerts,
+		&tls.Config{
+			Certificates: firstCerts,
+			MinVersion:   tls.VersionTLS13,
+			RootCAs:      caPool,
+		},
+	)
+	defer stop()
+
+	testTLSRotationClient(t, client, proto, firstCerts)
+
+	// Response is not read, so connection is in use
+	inUse := sendTLSRotationRequest(t, client, proto)
+
+	//nolint:forcetypeassert // Transport type is fully controlled
+	client.Transport.(*Transport).SetTLSConfig(
+		&tls.Config{
+			Certificates: secondCerts,
+			MinVersion:   tls.VersionTLS13,
+			RootCAs:      caPool,
+		},
+	)
+
+	body, err := io.ReadAll(inUse.Body)
+	require.NoError(t, err)
+	require.NoError(t, inUse.Body.Close())
+	require.Equal(t, firstCerts[0].Certificate[0], body)
+
+	// HTTP/2 connection that is in use is kept, so it is closed when it becomes idle
+	if useHTTP2 {
+		client.CloseIdleConnections()
+	}
+
+	testTLSRotated(t, client, proto, firstCerts, secondCerts)
+}
+
+func TestTransportRotateTLS(t *testing.T) {
+	testTransportRotateTLSBase(t, false)
+}
+
+func TestTransportRotateTLSHTTP2(t *testing.T) {
+	testTransportRotateTLSBase(t, true)
+}
+
+func testTransportRotateTLSBase(t *testing.T, useHTTP2 bool) {
+	caPool, serverCerts, firstCerts := genTempPKI(t, testHostname)
+	_, _, secondCerts := genTempPKI(t, testHostname)
+
+	var current atomic.Pointer[tls.Certificate]
+
+	current.Store(&firstCerts[0])
+
+	client, proto, stop := prepareTLSRotation(
+		t,
+		useHTTP2,
+		serverCerts,
+		&tls.Config{
+			GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+				return current.Load(), nil
+			},
+			MinVersion: tls.VersionTLS13,
+			RootCAs:    caPool,
+		},
+	)
+	defer stop()
+
+	testTLSRotationClient(t, client, proto, firstCerts)
+
+	current.Store(&secondCerts[0])
+
+	//nolint:forcetypeassert // Transport type is fully controlled
+	client.Transport.(*Transport).RotateTLS()
+
+	testTLSRotated(t, client, proto, firstCerts, secondCerts)
+}
+
+func prepareTLSRotation(
+	t *testing.T,
+	useHTTP2 bool,
+	serverCerts []tls.Certificate,
+	clientConfig *tls.Config,
+) (*http.Client, string, func()) {
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	listenTLSConfig := &tls.Config{
+		Certificates: serverCerts,
+		ClientAuth:   tls.RequireAnyClientCert,
+		MinVersion:   tls.VersionTLS13,
+	}
+
+	proto := http1Proto
+
+	if useHTTP2 {
+		listenTLSConfig.NextProtos = []string{"h2"}
+		proto = http2Proto
+	}
+
+	listener, err := tls.Listen(unixNetworkName, socketPath, listenTLSConfig)
+	require.NoError(t, err)
+
+	server := &http.Server{
+		Handler: http.HandlerFunc(
+			func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write(r.TLS.PeerCertificates[0].Raw)
+			},
+		),
+		ReadTimeout: time.Second,
+		TLSConfig:   listenTLSConfig,
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	stop := func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+
+	upstream := cloneDefaultHTTPTransport(t)
+	upstream.TLSClientConfig = clientConfig
+
+	trt, err := New(&keeper, upstream)
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	return client, proto, stop
+}
+
+// Connection established with the previous configuration can be reused once if it
+// is returned to the pool asynchronously after the rotation.
+func testTLSRotated(
+	t *testing.T,
+	client *http.Client,
+	proto string,
+	previous []tls.Certificate,
+	next []tls.Certificate,
+) {
+	const requests = 5
+
+	reused := 0
+
+	for range requests {
+		switch testTLSRotationRequest(t, client, proto) {
+		case string(previous[0].Certificate[0]):
+			reused++
+		case string(next[0].Certificate[0]):
+		default:
+			require.FailNow(t, "unexpected client certificate")
+		}
+	}
+
+	require.LessOrEqual(t, reused, 1)
+}
+
+func testTLSRotationClient(
+	t *testing.T,
+	client *http.Client,
+	proto string,
+	expected []tls.Certificate,
+) {
+	require.Equal(t, string(expected[0].Certificate[0]), testTLSRotationRequest(t, client, proto))
+}
+
+func testTLSRotationRequest(t *testing.T, client *http.Client, proto string) string {
+	resp := sendTLSRotationRequest(t, client, proto)
+
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	return string(body)
+}
+
+func sendTLSRotationRequest(t *testing.T, client *http.Client, proto string) *http.Response {
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTPS,
+		Host:   testHostname,
+	}
+
+	request, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodGet,
+		requestURL.String(),
+		http.NoBody,
+	)
+	require.NoError(t, err)
+
+	resp, err := client.Do(request)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, proto, resp.Proto)
+
+	return resp
+}
@@ -3,9 +3,15 @@ package utr
 import (
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"net"
 	"net/http"
+	"net/http/httptrace"
+	"slices"
+	"sync/atomic"
+	"syscall"
+	"time"
 )
 
 // Provides adjusting of a Unix domain socket transport.
@@ -19,8 +25,18 @@ type Transport struct {
 	schemeHTTPS string
 	upstream    *http.Transport
 
-	dialer    *net.Dialer
-	tlsDialer *tls.Dialer
+	balancer        balancer
+	conns           *connRegistry
+	dialFunc        DialFunc
+	dialMiddlewares []func(next DialFunc) DialFunc
+	dialTimeout     time.Duration
+	dialer          *net.Dialer
+	outliers        *outlierDetector
+	peerVerifiers   []func(hostname string, peer Credentials) error
+	poolSettings    []func(base *http.Transport)
+	tlsConfigFunc   func(hostname string) (*tls.Config, error)
+	tlsState        atomic.Pointer[tlsConfigState]
+	unsubscribe     func()
 }
 
 // Sets URL scheme for operation HTTP via Unix domain socket.
@@ -61,6 +77,91 @@ func WithSchemeHTTPS(scheme string) Adjuster {
 	return adj
 }
 
+// Sets dialer of Unix domain sockets, e.g. to set keep-alive or control function.
+// The dialer is copied, so it can be reused after the call.
+//
+// If dialer is not set, then dialer with default settings will be used.
+func WithDialer(dialer *net.Dialer) Adjuster {
+	adj := func(trt *Transport) error {
+		if dialer == nil {
+			return ErrDialerEmpty
+		}
+
+		copied := *dialer
+		trt.dialer = &copied
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets timeout of dialing of Unix domain socket. Takes precedence over the timeout of
+// the dialer set using [WithDialer] function.
+func WithDialTimeout(timeout time.Duration) Adjuster {
+	adj := func(trt *Transport) error {
+		if timeout <= 0 {
+			return fmt.Errorf("%w: %s", ErrTimeoutInvalid, timeout)
+		}
+
+		trt.dialTimeout = timeout
+
+		return nil
+	}
+
+	return adj
+}
+
+// Dials Unix domain socket by path resolved for hostname.
+type DialFunc func(ctx context.Context, hostname, path string) (net.Conn, error)
+
+// Adds middleware of dialing of Unix domain sockets, e.g. to wrap connections for
+// counting of bytes, rate limiting or fault injection.
+//
+// Middleware receives the next dial function and returns dial function that is
+// called instead of it. Middlewares are applied to connections of both HTTP and HTTPS
+// via Unix domain socket, for HTTPS the TLS connection is established over
+// the connection returned by middleware. Verification of peer credentials is performed
+// before the connection is returned to middleware, obtained credentials are also passed
+// to the hook set using [ContextWithPeerHook] function, so middleware must pass
+// the received context or one derived from it to the next function. Middleware added
+// first is called first.
+func WithDialMiddleware(middleware func(next DialFunc) DialFunc) Adjuster {
+	adj := func(trt *Transport) error {
+		if middleware == nil {
+			return ErrDialMiddlewareEmpty
+		}
+
+		trt.dialMiddlewares = append(trt.dialMiddlewares, middleware)
+
+		return nil
+	}
+
+	return adj
+}
+
+// Sets function that provides TLS client configuration for operation HTTPS via Unix
+// domain socket by hostname.
+//
+// Function is called on each establishing of TLS connection. If function returns nil
+// configuration, then TLS client configuration of the upstream [http.Transport] is used.
+// If function returns an error, then establishing of connection fails with it. When
+// configurations provided by function are changed, [Transport.RotateTLS] can be used
+// to retire connections established with the previous ones.
+func WithTLSConfigFunc(fn func(hostname string) (*tls.Config, error)) Adjuster {
+	adj := func(trt *Transport) error {
+		if fn == nil {
+			return ErrTLSConfigFuncEmpty
+		}
+
+		trt.tlsConfigFunc = fn
+
+		return nil
+	}
+
+	return adj
+}
+
 // Creates new Unix domain socket transport with upstream [http.Transport]. For Unix domain socket
 // schemes will be used a clone of an upstream [http.Transport], for other schemes
 // an upstream [http.Transport] will be used directly.
@@ -74,6 +175,37 @@ func WithSchemeHTTPS(scheme string) Adjuster {
 // If URL schemes for operation HTTP and HTTPS via Unix domain socket are not set using
 // [WithSchemeHTTP] and [WithSchemeHTTPS] functions, then URL schemes
 // [DefaultSchemeHTTP] and [DefaultSchemeHTTPS] will be used.
+//
+// For HTTPS via Unix domain socket, if server name is not set in the TLS client
+// configuration of the upstream [http.Transport] or in the one provided by the function
+// set using [WithTLSConfigFunc] function, then hostname from the URL is used
+// as server name for SNI and verification of the server certificate.
+//
+// TLS client configuration of the upstream [http.Transport] is taken at creation, it
+// can be replaced later using [Transport.SetTLSConfig] method.
+//
+// Settings of the pool of connections to Unix domain sockets are inherited from
+// the upstream [http.Transport], they can be set separately using [WithMaxIdleConns],
+// [WithMaxIdleConnsPerHost], [WithMaxConnsPerHost] and [WithIdleConnTimeout] functions.
+//
+// Dial function of the upstream [http.Transport] is not used for Unix domain sockets,
+// dialing can be adjusted using [WithDialer] and [WithDialTimeout] functions.
+//
+// If the [Resolver] implements the [ContextResolver] interface, then it will be used
+// with the context of the dial.
+//
+// If the [Resolver] implements the [MultiResolver] or [ContextMultiResolver]
+// interfaces, then new connections will be spread across paths to Unix domain sockets
+// according to the policy set using [WithBalancing] function. If dialing of a path
+// fails because nothing listens on it or it does not exist, then the next path is
+// dialed, and if all of them fail, then errors of all attempts are returned joined.
+//
+// If the [Resolver] implements the [Notifier] interface, then connections to the path
+// to Unix domain socket are retired when its mapping is removed: idle HTTP/1
+// connections are closed immediately, connections that are in use are closed after
+// completion of the current or of the next request over them.
+// In this case the transport should be released using [Transport.Close] method when
+// it is no longer needed, otherwise the [Resolver] keeps reference to it.
 func New(resolver Resolver, upstream http.RoundTripper, opts ...Adjuster) (*Transport, error) {
 	if resolver == nil {
 		return nil, ErrResolverEmpty
@@ -95,6 +227,16 @@ func New(resolver Resolver, upstream http.RoundTripper, opts ...Adjuster) (*Tran
 		}
 	}
 
+	if trt.dialTimeout != 0 {
+		trt.dialer.Timeout = trt.dialTimeout
+	}
+
+	trt.dialFunc = trt.dialVerified
+
+	for _, middleware := range slices.Backward(trt.dialMiddlewares) {
+		trt.dialFunc = middleware(trt.dialFunc)
+	}
+
 	if trt.schemeHTTP == "" {
 		trt.schemeHTTP = DefaultSchemeHTTP
 	}
@@ -105,13 +247,29 @@ func New(resolver Resolver, upstream http.RoundTripper, opts ...Adjuster) (*Tran
 
 	trt.base = trt.upstream.Clone()
 
-	trt.tlsDialer = &tls.Dialer{
-		Config: trt.base.TLSClientConfig,
-	}
-
 	trt.base.DialContext = trt.dial
 	trt.base.DialTLSContext = trt.dialTLS
 
+	for _, setting := range trt.poolSettings {
+		setting(trt.base)
+	}
+
+	trt.tlsState.Store(&tlsConfigState{config: trt.base.TLSClientConfig})
+
+	notifier, casted := resolver.(Notifier)
+
+	if casted || trt.outliers != nil {
+		trt.conns = newConnRegistry()
+	}
+
+	if trt.outliers != nil {
+		trt.outliers.onEject = trt.conns.retire
+	}
+
+	if casted {
+		trt.unsubscribe = notifier.Subscribe(trt.dropPath)
+	}
+
 	return trt, nil
 }
 
@@ -136,11 +294,62 @@ func (trt *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
 		return trt.upstream.RoundTrip(req)
 	}
 
-	cloned := req.Clone(req.Context())
+	var (
+		cloned *http.Request
+		conn   net.Conn
+	)
+
+	ctx := withPeerTrace(req.Context())
+
+	if trt.outliers != nil || trt.conns != nil || trt.tlsState.Load().generation != 0 {
+		gotConn := func(info httptrace.GotConnInfo) {
+			conn = info.Conn
+
+			tracked := getTrackedConn(info.Conn)
+
+			if tracked != nil && info.Reused {
+				tracked.uses.Add(1)
+			}
+
+			// Connection established with the previous TLS configuration, retired
+			// one or one to the ejected path is closed after the request
+			if trt.isStaleTLS(info.Conn) || trt.isRetired(tracked) {
+				cloned.Close = true
+			}
+		}
+
+		// Retired connection is closed when it is returned to the pool
+		putIdleConn := func(err error) {
+			tracked := getTrackedConn(conn)
+
+			if err != nil || tracked == nil {
+				return
+			}
+
+			if tracked.uses.Add(-1) == 0 && tracked.retired.Load() {
+				_ = tracked.Close()
+			}
+		}
+
+		trace := &httptrace.ClientTrace{
+			GotConn:     gotConn,
+			PutIdleConn: putIdleConn,
+		}
+
+		ctx = httptrace.WithClientTrace(ctx, trace)
+	}
+
+	cloned = req.Clone(ctx)
 
 	trt.replaceScheme(cloned)
 
-	return trt.base.RoundTrip(cloned)
+	resp, err := trt.base.RoundTrip(cloned)
+
+	if conn != nil {
+		trt.outliers.reportConn(ctx, conn, err)
+	}
+
+	return resp, err
 }
 
 // Like the [http.Transport.CloseIdleConnections].
@@ -149,6 +358,30 @@ func (trt *Transport) CloseIdleConnections() {
 	trt.upstream.CloseIdleConnections()
 }
 
+// Releases the transport: cancels subscription to the [Resolver], if it implements
+// the [Notifier] interface, and closes idle connections to Unix domain sockets.
+//
+// Transport must not be used after closing.
+func (trt *Transport) Close() {
+	if trt.unsubscribe != nil {
+		trt.unsubscribe()
+	}
+
+	trt.base.CloseIdleConnections()
+}
+
+func (trt *Transport) isRetired(conn *trackedConn) bool {
+	if conn == nil {
+		return false
+	}
+
+	return conn.retired.Load() || trt.outliers.isEjected(conn.path)
+}
+
+func (trt *Transport) dropPath(_, path string) {
+	trt.conns.retire(path)
+}
+
 func (trt *Transport) replaceScheme(req *http.Request) {
 	switch req.URL.Scheme {
 	case trt.schemeHTTP:
@@ -163,12 +396,7 @@ func (trt *Transport) dial(ctx context.Context, _, addr string) (net.Conn, error
 	// the transport from the net/http package
 	hostname, _, _ := net.SplitHostPort(addr)
 
-	path, err := trt.resolver.LookupPath(hostname)
-	if err != nil {
-		return nil, err
-	}
-
-	return trt.dialer.DialContext(ctx, unixNetworkName, path)
+	return trt.dialUnix(ctx, hostname, 0)
 }
 
 func (trt *Transport) dialTLS(ctx context.Context, _, addr string) (net.Conn, error) {
@@ -176,10 +404,206 @@ func (trt *Transport) dialTLS(ctx context.Context, _, addr string) (net.Conn, er
 	// the transport from the net/http package
 	hostname, _, _ := net.SplitHostPort(addr)
 
-	path, err := trt.resolver.LookupPath(hostname)
+	state := trt.tlsState.Load()
+
+	conn, err := trt.dialUnix(ctx, hostname, state.generation)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := trt.tlsConfig(state.config, hostname)
 	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	tlsConn := tls.Client(conn, config)
+
+	if err := trt.handshake(ctx, tlsConn); err != nil {
+		trt.outliers.reportConn(ctx, conn, err)
+
+		_ = conn.Close()
+
 		return nil, err
 	}
 
-	return trt.tlsDialer.DialContext(ctx, unixNetworkName, path)
+	return tlsConn, nil
+}
+
+// Transport from the net/http package does not limit handshake if the TLS dial
+// function is set, so it is limited here.
+func (trt *Transport) handshake(ctx context.Context, conn *tls.Conn) error {
+	if trt.base.TLSHandshakeTimeout > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, trt.base.TLSHandshakeTimeout)
+		defer cancel()
+	}
+
+	return conn.HandshakeContext(ctx)
+}
+
+// Generation of TLS configuration is zero for connections without TLS.
+func (trt *Transport) dialUnix(
+	ctx context.Context,
+	hostname string,
+	generation uint64,
+) (net.Conn, error) {
+	paths, err := lookupPaths(ctx, trt.resolver, hostname)
+	if err != nil {
+		return nil, err
+	}
+
+	errs := make([]error, 0, len(paths))
+
+	for _, path := range trt.balancer.order(hostname, paths) {
+		if !trt.outliers.allow(path) {
+			errs = append(errs, fmt.Errorf("%w: %s", ErrPathEjected, path))
+			continue
+		}
+
+		conn, err := trt.dialPath(ctx, hostname, path, generation)
+		if err == nil {
+			return conn, nil
+		}
+
+		errs = append(errs, err)
+
+		if !isFailoverError(err) {
+			break
+		}
+	}
+
+	// Error of the single attempt is returned as is to keep it identical to
+	// the error of the dialer
+	if len(errs) == 1 {
+		return nil, errs[0]
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+func (trt *Transport) dialPath(
+	ctx context.Context,
+	hostname string,
+	path string,
+	generation uint64,
+) (net.Conn, error) {
+	var peer *peerResult
+
+	// Connection wrapped by middlewares can hide the connection to Unix domain socket,
+	// so peer credentials are obtained before wrapping
+	if len(trt.dialMiddlewares) != 0 {
+		peer = &peerResult{}
+		ctx = context.WithValue(ctx, peerResultKey{}, peer)
+	}
+
+	conn, err := trt.dialFunc(ctx, hostname, path)
+	if err != nil {
+		trt.outliers.report(ctx, path, err)
+		return nil, err
+	}
+
+	return trt.track(conn, path, generation, peer), nil
+}
+
+// Dial function that is wrapped by dial middlewares.
+func (trt *Transport) dialVerified(ctx context.Context, hostname, path string) (net.Conn, error) {
+	conn, err := trt.dialer.DialContext(ctx, unixNetworkName, path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := trt.verifyPeer(ctx, conn, hostname, path); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	return conn, nil
+}
+
+// Wraps the connection if its path, generation of TLS configuration, peer credentials
+// or closing must be tracked. Peer credentials can be nil.
+func (trt *Transport) track(
+	conn net.Conn,
+	path string,
+	generation uint64,
+	peer *peerResult,
+) net.Conn {
+	release := trt.balancer.acquire(path)
+
+	if release == nil && trt.outliers == nil && trt.conns == nil && generation == 0 && peer == nil {
+		return conn
+	}
+
+	tracked := newTrackedConn(conn, path, release)
+	tracked.generation = generation
+	tracked.peer = peer
+
+	if trt.conns != nil {
+		trt.conns.add(tracked)
+
+		tracked.release = func() {
+			trt.conns.delete(tracked)
+
+			if release != nil {
+				release()
+			}
+		}
+	}
+
+	return tracked
+}
+
+func isFailoverError(err error) bool {
+	return errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENOENT)
+}
+
+func (trt *Transport) tlsConfig(config *tls.Config, hostname string) (*tls.Config, error) {
+	if trt.tlsConfigFunc != nil {
+		custom, err := trt.tlsConfigFunc(hostname)
+		if err != nil {
+			return nil, err
+		}
+
+		if custom != nil {
+			config = custom
+		}
+	}
+
+	// Transport from the net/http package sets protocols, e.g. HTTP/2, only to its own
+	// configuration, so they are inherited by other ones
+	var protos []string
+
+	if config == nil || len(config.NextProtos) == 0 {
+		if base := trt.base.TLSClientConfig; base != nil {
+			protos = base.NextProtos
+		}
+	}
+
+	if config == nil {
+		config = &tls.Config{
+			MinVersion: tls.VersionTLS12,
+			NextProtos: slices.Clone(protos),
+			ServerName: hostname,
+		}
+
+		return config, nil
+	}
+
+	if config.ServerName != "" && len(protos) == 0 {
+		return config, nil
+	}
+
+	config = config.Clone()
+
+	if config.ServerName == "" {
+		config.ServerName = hostname
+	}
+
+	if len(protos) != 0 {
+		config.NextProtos = slices.Clone(protos)
+	}
+
+	return config, nil
 }
@@ -6,7 +6,6 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"net"
 	"net/http"
 	"time"
 
@@ -35,7 +34,7 @@ func ExampleTransport() {
 	serverErr := make(chan error)
 	defer close(serverErr)
 
-	listener, err := net.Listen("unix", socketPath)
+	listener, err := utr.Listen(context.Background(), socketPath)
 	if err != nil {
 		panic(err)
 	}
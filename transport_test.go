@@ -1,18 +1,24 @@
 package utr
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
 	"crypto/x509/pkix"
+	"errors"
 	"io"
 	"math/big"
 	"net"
 	"net/http"
 	"net/url"
 	"path/filepath"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"syscall"
 	"testing"
 	"time"
 
@@ -45,6 +51,311 @@ func TestWithSchemeHTTPS(t *testing.T) {
 	require.Equal(t, "uhttps", trt.schemeHTTPS)
 }
 
+func TestWithDialer(t *testing.T) {
+	trt := &Transport{}
+
+	require.Error(t, WithDialer(nil)(trt))
+	require.Nil(t, trt.dialer)
+
+	dialer := &net.Dialer{KeepAlive: time.Minute}
+
+	require.NoError(t, WithDialer(dialer)(trt))
+	require.Equal(t, dialer, trt.dialer)
+	require.NotSame(t, dialer, trt.dialer)
+}
+
+func TestWithDialTimeout(t *testing.T) {
+	trt := &Transport{}
+
+	require.Error(t, WithDialTimeout(0)(trt))
+	require.Zero(t, trt.dialTimeout)
+
+	require.NoError(t, WithDialTimeout(time.Second)(trt))
+	require.Equal(t, time.Second, trt.dialTimeout)
+}
+
+func TestTransportDialer(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	listener, err := Listen(t.Context(), socketPath)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, listener.Close())
+	}()
+
+	var controlled []string
+
+	dialer := &net.Dialer{
+		Control: func(network, address string, _ syscall.RawConn) error {
+			controlled = append(controlled, network, address)
+			return nil
+		},
+		Timeout: time.Minute,
+	}
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+
+	trt, err := New(
+		&keeper,
+		cloneDefaultHTTPTransport(t),
+		WithDialTimeout(time.Second),
+		WithDialer(dialer),
+	)
+	require.NoError(t, err)
+	require.Equal(t, time.Second, trt.dialer.Timeout)
+	require.Equal(t, time.Minute, dialer.Timeout)
+
+	conn, err := trt.dial(t.Context(), "", testHostname+":80")
+	require.NoError(t, err)
+	require.NoError(t, conn.Close())
+	require.Equal(t, []string{unixNetworkName, socketPath}, controlled)
+
+	trt, err = New(&keeper, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+	require.Zero(t, trt.dialer.Timeout)
+}
+
+func TestTransportHandshakeTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	socketPath := filepath.Join(t.TempDir(), testSocketPath)
+
+	var blank net.ListenConfig
+
+	listener, err := blank.Listen(t.Context(), unixNetworkName, socketPath)
+	require.NoError(t, err)
+
+	defer func() {
+		require.NoError(t, listener.Close())
+	}()
+
+	// Accepted connections are held silent, so handshake never completes
+	go func() {
+		var conns []net.Conn
+
+		defer func() {
+			for _, conn := range conns {
+				_ = conn.Close()
+			}
+		}()
+
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			conns = append(conns, conn)
+		}
+	}()
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+
+	httpTransport := cloneDefaultHTTPTransport(t)
+	httpTransport.TLSHandshakeTimeout = timeout
+
+	trt, err := New(&keeper, httpTransport)
+	require.NoError(t, err)
+
+	startedAt := time.Now()
+
+	conn, err := trt.dialTLS(t.Context(), "", testHostname+":443")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Nil(t, conn)
+	require.Less(t, time.Since(startedAt), time.Minute)
+}
+
+func TestWithDialMiddleware(t *testing.T) {
+	trt := &Transport{}
+
+	require.Error(t, WithDialMiddleware(nil)(trt))
+	require.Empty(t, trt.dialMiddlewares)
+}
+
+type countingConn struct {
+	net.Conn
+
+	read *atomic.Int64
+}
+
+func (cn *countingConn) Read(data []byte) (int, error) {
+	read, err := cn.Conn.Read(data)
+	cn.read.Add(int64(read))
+
+	return read, err
+}
+
+func TestTransportDialMiddleware(t *testing.T) {
+	const plainHostname = "plain"
+
+	var (
+		plainPath = filepath.Join(t.TempDir(), testSocketPath)
+		tlsPath   = filepath.Join(t.TempDir(), testSocketPath)
+	)
+
+	caPool, serverCerts, _ := genTempPKI(t, testHostname)
+
+	plainListener, err := Listen(t.Context(), plainPath)
+	require.NoError(t, err)
+
+	listenTLSConfig := &tls.Config{
+		Certificates: serverCerts,
+		MinVersion:   tls.VersionTLS13,
+	}
+
+	tlsListener, err := tls.Listen(unixNetworkName, tlsPath, listenTLSConfig)
+	require.NoError(t, err)
+
+	servers := make([]*http.Server, 0, 2)
+	serverErrs := make([]chan error, 0, 2)
+
+	defer func() {
+		for id, server := range servers {
+			require.NoError(t, server.Shutdown(t.Context()))
+			require.Equal(t, http.ErrServerClosed, <-serverErrs[id])
+		}
+	}()
+
+	for _, listener := range []net.Listener{plainListener, tlsListener} {
+		server := &http.Server{
+			Handler:     http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+			ReadTimeout: time.Second,
+		}
+
+		serverErr := make(chan error, 1)
+
+		go func() {
+			serverErr <- server.Serve(listener)
+		}()
+
+		servers = append(servers, server)
+		serverErrs = append(serverErrs, serverErr)
+	}
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(plainHostname, plainPath))
+	require.NoError(t, keeper.AddPath(testHostname, tlsPath))
+
+	const countingMark = "counting"
+
+	var (
+		dialed []string
+		mutex  sync.Mutex
+		read   atomic.Int64
+	)
+
+	// Dial functions are called from goroutines of the transport
+	record := func(values ...string) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		dialed = append(dialed, values...)
+	}
+
+	recording := func(next DialFunc) DialFunc {
+		dial := func(ctx context.Context, hostname, path string) (net.Conn, error) {
+			record(hostname, path)
+			return next(ctx, hostname, path)
+		}
+
+		return dial
+	}
+
+	counting := func(next DialFunc) DialFunc {
+		dial := func(ctx context.Context, hostname, path string) (net.Conn, error) {
+			record(countingMark)
+
+			conn, err := next(ctx, hostname, path)
+			if err != nil {
+				return nil, err
+			}
+
+			return &countingConn{Conn: conn, read: &read}, nil
+		}
+
+		return dial
+	}
+
+	httpTransport := cloneDefaultHTTPTransport(t)
+
+	httpTransport.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS13,
+		RootCAs:    caPool,
+	}
+
+	trt, err := New(
+		&keeper,
+		httpTransport,
+		WithDialMiddleware(recording),
+		WithDialMiddleware(counting),
+	)
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	for _, requestURL := range []url.URL{
+		{Scheme: DefaultSchemeHTTP, Host: plainHostname},
+		{Scheme: DefaultSchemeHTTPS, Host: testHostname},
+	} {
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	mutex.Lock()
+	recorded := slices.Clone(dialed)
+	mutex.Unlock()
+
+	require.Equal(
+		t,
+		[]string{plainHostname, plainPath, countingMark, testHostname, tlsPath, countingMark},
+		recorded,
+	)
+	require.Positive(t, read.Load())
+
+	errInjected := errors.New("injected fault")
+
+	trt, err = New(
+		&keeper,
+		cloneDefaultHTTPTransport(t),
+		WithDialMiddleware(
+			func(DialFunc) DialFunc {
+				dial := func(context.Context, string, string) (net.Conn, error) {
+					return nil, errInjected
+				}
+
+				return dial
+			},
+		),
+	)
+	require.NoError(t, err)
+
+	conn, err := trt.dial(t.Context(), "", plainHostname+":80")
+	require.ErrorIs(t, err, errInjected)
+	require.Nil(t, conn)
+
+	conn, err = trt.dialTLS(t.Context(), "", testHostname+":443")
+	require.ErrorIs(t, err, errInjected)
+	require.Nil(t, conn)
+}
+
 func TestNewBadResolver(t *testing.T) {
 	trt, err := New(nil, &http.Transport{})
 	require.Error(t, err)
@@ -215,7 +526,7 @@ func testTransportTLSBase(t *testing.T, socketPath string, useHTTP2 bool) {
 	const requestPath = "/request/path"
 
 	message := prepareMessage(t)
-	caPool, serverCerts, clientCerts := genTempPKI(t, socketPath)
+	caPool, serverCerts, clientCerts := genTempPKI(t, testHostname)
 
 	listenTLSConfig := &tls.Config{
 		Certificates: serverCerts,
@@ -341,6 +652,496 @@ func testTransportTLSBase(t *testing.T, socketPath string, useHTTP2 bool) {
 	client.CloseIdleConnections()
 }
 
+func TestTransportClose(t *testing.T) {
+	var keeper Keeper
+
+	trt, err := New(&keeper, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+	require.Len(t, keeper.notifier.subscriptions, 1)
+
+	trt.Close()
+	require.Empty(t, keeper.notifier.subscriptions)
+
+	trt, err = New(Decoder{}, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	trt.Close()
+}
+
+func TestTransportRemovePath(t *testing.T) {
+	const (
+		otherHostname = "other"
+		requestPath   = "/request/path"
+	)
+
+	var (
+		socketPath = filepath.Join(t.TempDir(), testSocketPath)
+		otherPath  = filepath.Join(t.TempDir(), testSocketPath)
+	)
+
+	var router http.ServeMux
+
+	router.HandleFunc(
+		requestPath,
+		func(w http.ResponseWriter, _ *http.Request) {
+			w.WriteHeader(http.StatusOK)
+		},
+	)
+
+	servers := make([]*http.Server, 0, 2)
+	serverErrs := make([]chan error, 0, 2)
+	closed := make([]*atomic.Int64, 0, 2)
+
+	defer func() {
+		for id, server := range servers {
+			require.NoError(t, server.Shutdown(t.Context()))
+			require.Equal(t, http.ErrServerClosed, <-serverErrs[id])
+		}
+	}()
+
+	for _, path := range []string{socketPath, otherPath} {
+		var counter atomic.Int64
+
+		server := &http.Server{
+			Handler:     &router,
+			ReadTimeout: time.Second,
+			ConnState: func(_ net.Conn, state http.ConnState) {
+				if state == http.StateClosed {
+					counter.Add(1)
+				}
+			},
+		}
+
+		var blank net.ListenConfig
+
+		listener, err := blank.Listen(t.Context(), unixNetworkName, path)
+		require.NoError(t, err)
+
+		serverErr := make(chan error, 1)
+
+		go func() {
+			serverErr <- server.Serve(listener)
+		}()
+
+		servers = append(servers, server)
+		serverErrs = append(serverErrs, serverErr)
+		closed = append(closed, &counter)
+	}
+
+	var keeper Keeper
+
+	require.NoError(t, keeper.AddPath(testHostname, socketPath))
+	require.NoError(t, keeper.AddPath(otherHostname, otherPath))
+
+	trt, err := New(&keeper, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	newRequest := func(hostname string) *http.Request {
+		requestURL := url.URL{
+			Scheme: DefaultSchemeHTTP,
+			Host:   hostname,
+			Path:   requestPath,
+		}
+
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		return request
+	}
+
+	for _, hostname := range []string{testHostname, otherHostname} {
+		resp, err := client.Do(newRequest(hostname))
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	require.Zero(t, closed[0].Load())
+	require.Zero(t, closed[1].Load())
+
+	require.NoError(t, keeper.RemovePath(testHostname))
+
+	// Only idle connection to the removed path is closed
+	require.Eventually(
+		t,
+		func() bool { return closed[0].Load() == 1 },
+		time.Second,
+		time.Millisecond,
+	)
+
+	require.Zero(t, closed[1].Load())
+
+	//nolint:bodyclose // False positive
+	resp, err := client.Do(newRequest(testHostname))
+	require.Error(t, err)
+	require.Nil(t, resp)
+
+	// Connection that is in use is closed when it is returned to the pool
+	resp, err = client.Do(newRequest(otherHostname))
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+
+	require.NoError(t, keeper.ReplacePath(otherHostname, socketPath))
+	require.Zero(t, closed[1].Load())
+
+	_, err = io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	require.Eventually(
+		t,
+		func() bool { return closed[1].Load() == 1 },
+		time.Second,
+		time.Millisecond,
+	)
+}
+
+type blockingResolver struct{}
+
+func (blockingResolver) LookupPath(string) (string, error) {
+	return testSocketPath, nil
+}
+
+func (blockingResolver) LookupPathContext(ctx context.Context, _ string) (string, error) {
+	<-ctx.Done()
+	return "", ctx.Err()
+}
+
+func TestTransportContextResolver(t *testing.T) {
+	trt, err := New(blockingResolver{}, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	for _, scheme := range []string{DefaultSchemeHTTP, DefaultSchemeHTTPS} {
+		requestURL := url.URL{
+			Scheme: scheme,
+			Host:   testHostname,
+		}
+
+		ctx, cancel := context.WithTimeout(t.Context(), 10*time.Millisecond)
+
+		request, err := http.NewRequestWithContext(
+			ctx,
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		//nolint:bodyclose // False positive
+		resp, err := client.Do(request)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		require.Nil(t, resp)
+
+		cancel()
+	}
+}
+
+func TestTransportTLSConfig(t *testing.T) {
+	trt, err := New(&Keeper{}, &http.Transport{})
+	require.NoError(t, err)
+
+	config, err := trt.tlsConfig(trt.tlsState.Load().config, testHostname)
+	require.NoError(t, err)
+	require.Equal(t, testHostname, config.ServerName)
+
+	upstream := cloneDefaultHTTPTransport(t)
+	upstream.TLSClientConfig = &tls.Config{
+		MinVersion: tls.VersionTLS13,
+	}
+
+	trt, err = New(&Keeper{}, upstream)
+	require.NoError(t, err)
+
+	config, err = trt.tlsConfig(trt.tlsState.Load().config, testHostname)
+	require.NoError(t, err)
+	require.Equal(t, testHostname, config.ServerName)
+	require.Equal(t, uint16(tls.VersionTLS13), config.MinVersion)
+	require.Empty(t, trt.base.TLSClientConfig.ServerName)
+
+	upstream.TLSClientConfig.ServerName = "server.name"
+
+	trt, err = New(&Keeper{}, upstream)
+	require.NoError(t, err)
+
+	config, err = trt.tlsConfig(trt.tlsState.Load().config, testHostname)
+	require.NoError(t, err)
+	require.Equal(t, "server.name", config.ServerName)
+}
+
+func TestWithTLSConfigFunc(t *testing.T) {
+	trt := &Transport{}
+
+	require.Error(t, WithTLSConfigFunc(nil)(trt))
+	require.Nil(t, trt.tlsConfigFunc)
+
+	fn := func(string) (*tls.Config, error) { return nil, nil } //nolint:nilnil // Test
+
+	require.NoError(t, WithTLSConfigFunc(fn)(trt))
+	require.NotNil(t, trt.tlsConfigFunc)
+}
+
+func TestTransportTLSConfigFunc(t *testing.T) {
+	testTransportTLSConfigFuncBase(t, false)
+}
+
+func TestTransportTLSConfigFuncHTTP2(t *testing.T) {
+	testTransportTLSConfigFuncBase(t, true)
+}
+
+func testTransportTLSConfigFuncBase(t *testing.T, useHTTP2 bool) {
+	const (
+		requestPath     = "/request/path"
+		unknownHostname = "unknown"
+	)
+
+	hostnames := []string{"first", "second"}
+	configs := make(map[string]*tls.Config, len(hostnames))
+	servers := make([]*http.Server, 0, len(hostnames))
+	serverErrs := make([]chan error, 0, len(hostnames))
+
+	defer func() {
+		for id, server := range servers {
+			require.NoError(t, server.Shutdown(t.Context()))
+			require.Equal(t, http.ErrServerClosed, <-serverErrs[id])
+		}
+	}()
+
+	var keeper Keeper
+
+	for _, hostname := range hostnames {
+		socketPath := filepath.Join(t.TempDir(), testSocketPath)
+		caPool, serverCerts, clientCerts := genTempPKI(t, hostname)
+
+		listenTLSConfig := &tls.Config{
+			Certificates: serverCerts,
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    caPool,
+			MinVersion:   tls.VersionTLS13,
+		}
+
+		if useHTTP2 {
+			listenTLSConfig.NextProtos = []string{"h2"}
+		}
+
+		listener, err := tls.Listen(unixNetworkName, socketPath, listenTLSConfig)
+		require.NoError(t, err)
+
+		server := &http.Server{
+			Handler: http.HandlerFunc(
+				func(w http.ResponseWriter, _ *http.Request) {
+					_, _ = io.WriteString(w, hostname)
+				},
+			),
+			ReadTimeout: time.Second,
+			TLSConfig:   listenTLSConfig,
+		}
+
+		serverErr := make(chan error, 1)
+
+		go func() {
+			serverErr <- server.Serve(listener)
+		}()
+
+		servers = append(servers, server)
+		serverErrs = append(serverErrs, serverErr)
+
+		require.NoError(t, keeper.AddPath(hostname, socketPath))
+
+		configs[hostname] = &tls.Config{
+			Certificates: clientCerts,
+			MinVersion:   tls.VersionTLS13,
+			RootCAs:      caPool,
+		}
+	}
+
+	require.NoError(t, keeper.AddPath(unknownHostname, keeper.Snapshot()[hostnames[0]]))
+
+	errUnknownHostname := errors.New("unknown hostname")
+
+	trt, err := New(
+		&keeper,
+		cloneDefaultHTTPTransport(t),
+		WithTLSConfigFunc(
+			func(hostname string) (*tls.Config, error) {
+				config, exists := configs[hostname]
+				if !exists {
+					return nil, errUnknownHostname
+				}
+
+				return config, nil
+			},
+		),
+	)
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	for _, hostname := range hostnames {
+		requestURL := url.URL{
+			Scheme: DefaultSchemeHTTPS,
+			Host:   hostname,
+			Path:   requestPath,
+		}
+
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+
+		output, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		require.Equal(t, hostname, string(output))
+		require.NoError(t, resp.Body.Close())
+
+		if useHTTP2 {
+			require.Equal(t, http2Proto, resp.Proto)
+		} else {
+			require.Equal(t, http1Proto, resp.Proto)
+		}
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTPS,
+		Host:   unknownHostname,
+		Path:   requestPath,
+	}
+
+	request, err := http.NewRequestWithContext(
+		t.Context(),
+		http.MethodGet,
+		requestURL.String(),
+		http.NoBody,
+	)
+	require.NoError(t, err)
+
+	//nolint:bodyclose // False positive
+	resp, err := client.Do(request)
+	require.ErrorIs(t, err, errUnknownHostname)
+	require.Nil(t, resp)
+
+	client.CloseIdleConnections()
+}
+
+type pathsResolver []string
+
+func (rsv pathsResolver) LookupPath(string) (string, error) {
+	return rsv[0], nil
+}
+
+func (rsv pathsResolver) LookupPaths(string) ([]string, error) {
+	return rsv, nil
+}
+
+func TestTransportFailover(t *testing.T) {
+	var (
+		missing = filepath.Join(t.TempDir(), testSocketPath)
+		refused = filepath.Join(t.TempDir(), testSocketPath)
+		alive   = filepath.Join(t.TempDir(), testSocketPath)
+	)
+
+	var blank net.ListenConfig
+
+	stale, err := blank.Listen(t.Context(), unixNetworkName, refused)
+	require.NoError(t, err)
+
+	//nolint:forcetypeassert // Listener type is determined by the network
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	require.NoError(t, stale.Close())
+
+	listener, err := Listen(t.Context(), alive)
+	require.NoError(t, err)
+
+	server := &http.Server{
+		Handler:     http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}),
+		ReadTimeout: time.Second,
+	}
+
+	serverErr := make(chan error, 1)
+
+	go func() {
+		serverErr <- server.Serve(listener)
+	}()
+
+	defer func() {
+		require.NoError(t, server.Shutdown(t.Context()))
+		require.Equal(t, http.ErrServerClosed, <-serverErr)
+	}()
+
+	httpTransport := cloneDefaultHTTPTransport(t)
+	httpTransport.DisableKeepAlives = true
+
+	trt, err := New(pathsResolver{missing, refused, alive}, httpTransport)
+	require.NoError(t, err)
+
+	client := &http.Client{
+		Transport: trt,
+	}
+
+	requestURL := url.URL{
+		Scheme: DefaultSchemeHTTP,
+		Host:   testHostname,
+	}
+
+	for range 3 {
+		request, err := http.NewRequestWithContext(
+			t.Context(),
+			http.MethodGet,
+			requestURL.String(),
+			http.NoBody,
+		)
+		require.NoError(t, err)
+
+		resp, err := client.Do(request)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, resp.StatusCode)
+		require.NoError(t, resp.Body.Close())
+	}
+
+	trt, err = New(pathsResolver{missing, refused}, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	conn, err := trt.dial(t.Context(), "", testHostname+":80")
+	require.ErrorIs(t, err, syscall.ENOENT)
+	require.ErrorIs(t, err, syscall.ECONNREFUSED)
+	require.ErrorContains(t, err, missing)
+	require.ErrorContains(t, err, refused)
+	require.Nil(t, conn)
+
+	invalid := strings.Repeat("x", maxPathSize+1)
+
+	trt, err = New(pathsResolver{invalid, alive}, cloneDefaultHTTPTransport(t))
+	require.NoError(t, err)
+
+	conn, err = trt.dial(t.Context(), "", testHostname+":80")
+	require.Error(t, err)
+	require.NotErrorIs(t, err, syscall.ENOENT)
+	require.NotErrorIs(t, err, syscall.ECONNREFUSED)
+	require.NotContains(t, err.Error(), alive)
+	require.Nil(t, conn)
+}
+
 func TestTransportPassthrough(t *testing.T) {
 	const requestPath = "/request/path"
 
@@ -435,7 +1236,7 @@ func cloneDefaultHTTPTransport(t *testing.T) *http.Transport {
 
 func genTempPKI(
 	t *testing.T,
-	path string,
+	hostname string,
 ) (*x509.CertPool, []tls.Certificate, []tls.Certificate) {
 	const (
 		certLifeTimeInDays = 1
@@ -468,7 +1269,7 @@ func genTempPKI(
 		},
 		NotBefore:   notBefore,
 		NotAfter:    notAfter,
-		DNSNames:    []string{path},
+		DNSNames:    []string{hostname},
 		KeyUsage:    x509.KeyUsageDigitalSignature,
 		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 	}
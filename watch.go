@@ -0,0 +1,34 @@
+package utr
+
+import (
+	"context"
+	"time"
+)
+
+// Kinds of changes of entries in a watched directory.
+type watchOp uint8
+
+const (
+	// Entry is created, e.g. Unix domain socket is bound
+	watchOpCreate watchOp = iota + 1
+	// Entry is written and closed or moved into the directory
+	watchOpWrite
+	// Entry is removed or moved out of the directory
+	watchOpRemove
+	// Events are lost, so state of the directory must be rechecked
+	watchOpOverflow
+)
+
+func poll(ctx context.Context, interval time.Duration, fn func()) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			fn()
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package utr
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"syscall"
+)
+
+const (
+	watchMask = syscall.IN_CREATE |
+		syscall.IN_CLOSE_WRITE |
+		syscall.IN_MOVED_TO |
+		syscall.IN_DELETE |
+		syscall.IN_MOVED_FROM
+
+	// Events are read by whole, so buffer must fit at least one event with
+	// the longest name
+	watchBufferSize = 64 * (syscall.SizeofInotifyEvent + syscall.NAME_MAX + 1)
+)
+
+// Watches changes of entries in a directory using inotify.
+type watcher struct {
+	dir  string
+	file *os.File
+}
+
+func newWatcher(dir string) (*watcher, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+
+	if _, err := syscall.InotifyAddWatch(fd, dir, watchMask); err != nil {
+		_ = syscall.Close(fd)
+		return nil, os.NewSyscallError("inotify_add_watch", err)
+	}
+
+	wch := &watcher{
+		dir: dir,
+		// File created from a descriptor in non-blocking mode is pollable, so reading
+		// is interrupted by closing
+		file: os.NewFile(uintptr(fd), dir),
+	}
+
+	return wch, nil
+}
+
+// Calls handler for each change of entries in the directory. Blocks until the context
+// is done, then closes the watcher and returns the context error.
+func (wch *watcher) run(ctx context.Context, handle func(name string, op watchOp)) error {
+	stop := context.AfterFunc(ctx, func() { _ = wch.file.Close() })
+
+	defer func() {
+		if stop() {
+			_ = wch.file.Close()
+		}
+	}()
+
+	buffer := make([]byte, watchBufferSize)
+
+	for {
+		read, err := wch.file.Read(buffer)
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+
+			return err
+		}
+
+		if err := wch.dispatch(buffer[:read], handle); err != nil {
+			return err
+		}
+	}
+}
+
+func (wch *watcher) dispatch(events []byte, handle func(name string, op watchOp)) error {
+	for len(events) >= syscall.SizeofInotifyEvent {
+		mask := binary.NativeEndian.Uint32(events[4:8])
+		length := int(binary.NativeEndian.Uint32(events[12:16]))
+		end := syscall.SizeofInotifyEvent + length
+
+		name := strings.TrimRight(string(events[syscall.SizeofInotifyEvent:end]), "\x00")
+
+		events = events[end:]
+
+		switch {
+		case mask&syscall.IN_IGNORED != 0:
+			return fmt.Errorf("%w: %s", ErrWatchStopped, wch.dir)
+		case mask&syscall.IN_Q_OVERFLOW != 0:
+			handle("", watchOpOverflow)
+		case mask&syscall.IN_CREATE != 0:
+			handle(name, watchOpCreate)
+		case mask&(syscall.IN_CLOSE_WRITE|syscall.IN_MOVED_TO) != 0:
+			handle(name, watchOpWrite)
+		case mask&(syscall.IN_DELETE|syscall.IN_MOVED_FROM) != 0:
+			handle(name, watchOpRemove)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux
+
+package utr
+
+import "context"
+
+// Watching of changes is not supported, so polling is used instead.
+type watcher struct{}
+
+func newWatcher(string) (*watcher, error) {
+	return nil, ErrWatchUnsupported
+}
+
+func (*watcher) run(context.Context, func(name string, op watchOp)) error {
+	return ErrWatchUnsupported
+}